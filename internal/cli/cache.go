@@ -0,0 +1,211 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/emorilebo/go_dep_audit/pkg/audit"
+	"github.com/spf13/cobra"
+)
+
+var pruneBefore string
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect and manage the metadata cache",
+}
+
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show the cache size, entry counts and the hit ratio of the last run",
+	Args:  cobra.NoArgs,
+	RunE:  runCacheStats,
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove expired cache entries",
+	Args:  cobra.NoArgs,
+	RunE:  runCachePrune,
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove every cache entry",
+	Args:  cobra.NoArgs,
+	RunE:  runCacheClear,
+}
+
+var cacheWarmCmd = &cobra.Command{
+	Use:   "warm",
+	Short: "Fetch the metadata of every project dependency into the cache",
+	Long: `Fetches everything an audit of the project needs, indirect dependencies
+included, so later runs can be served from the cache, e.g. in CI jobs
+without network access.`,
+	Args: cobra.NoArgs,
+	RunE: runCacheWarm,
+}
+
+func init() {
+	cachePruneCmd.Flags().StringVar(&pruneBefore, "before", "", "Also remove entries fetched before this date (YYYY-MM-DD or RFC 3339), even ones that never expire")
+	addProjectFlags(cacheWarmCmd)
+
+	cacheCmd.AddCommand(cacheStatsCmd)
+	cacheCmd.AddCommand(cachePruneCmd)
+	cacheCmd.AddCommand(cacheClearCmd)
+	cacheCmd.AddCommand(cacheWarmCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
+
+// diskCache returns the cache selected by the flags
+func diskCache() (*audit.DiskCache, error) {
+	cache := audit.NewDiskCache(cacheDir, cacheTTL)
+	if cache == nil || noCache {
+		return nil, fmt.Errorf("no cache directory, set --cache-dir")
+	}
+	return cache, nil
+}
+
+func runCacheStats(cmd *cobra.Command, args []string) error {
+	cache, err := diskCache()
+	if err != nil {
+		return err
+	}
+	stats, err := cache.Stats()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Cache: %s\n", stats.Dir)
+	fmt.Printf("Size: %s in %d entries%s\n", formatBytes(stats.Size), stats.Entries, kindSummary(stats.Kinds))
+	fmt.Printf("Expired: %d (TTL %s)\n", stats.Expired, cacheTTL)
+	fmt.Printf("Not found: %d\n", stats.NotFound)
+	if run := stats.LastRun; run != nil {
+		fmt.Printf("Last run: %s\n", run.Finished.Local().Format("2006-01-02 15:04:05"))
+		fmt.Printf("Hits: %d, misses: %d (%d revalidated), hit ratio %.1f%%\n",
+			run.Hits, run.Misses, run.Revalidated, 100*run.HitRatio())
+	}
+	return nil
+}
+
+func runCachePrune(cmd *cobra.Command, args []string) error {
+	cache, err := diskCache()
+	if err != nil {
+		return err
+	}
+	var before time.Time
+	if pruneBefore != "" {
+		if before, err = parseDate(pruneBefore); err != nil {
+			return err
+		}
+	}
+	removed, err := cache.Prune(before)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Removed %d entries from %s\n", removed, cache.Dir)
+	return nil
+}
+
+func runCacheClear(cmd *cobra.Command, args []string) error {
+	cache, err := diskCache()
+	if err != nil {
+		return err
+	}
+	if err := cache.Clear(); err != nil {
+		return err
+	}
+	fmt.Printf("Cleared %s\n", cache.Dir)
+	return nil
+}
+
+func runCacheWarm(cmd *cobra.Command, args []string) error {
+	cache, err := diskCache()
+	if err != nil {
+		return err
+	}
+	if offline {
+		return fmt.Errorf("warming the cache needs network access, drop --offline")
+	}
+	config, err := newAuditConfig()
+	if err != nil {
+		return err
+	}
+	config.IncludeIndirect = true
+
+	fmt.Printf("Warming the cache for %s...\n", projectPath)
+	modules := 0
+	if recursive {
+		multi, err := audit.AuditRecursive(context.Background(), config)
+		if err != nil {
+			return err
+		}
+		for _, p := range multi.Projects {
+			if p.Error != "" {
+				fmt.Printf("Warning: %s could not be audited: %s\n", p.ProjectPath, p.Error)
+			}
+		}
+		modules = len(multi.Rollup)
+	} else {
+		results, err := audit.AuditModules(context.Background(), config)
+		if err != nil {
+			return err
+		}
+		modules = len(results)
+	}
+
+	stats, err := cache.Stats()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Cached metadata for %d modules: %d entries, %s\n", modules, stats.Entries, formatBytes(stats.Size))
+	if run := stats.LastRun; run != nil {
+		fmt.Printf("Fetched %d, %d already cached\n", run.Misses, run.Hits)
+	}
+	return nil
+}
+
+// parseDate accepts a day or a full RFC 3339 time
+func parseDate(s string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, want YYYY-MM-DD or RFC 3339", s)
+	}
+	return t, nil
+}
+
+// kindSummary lists entry counts by kind, e.g. " (proxy 120, repo 8)"
+func kindSummary(kinds map[string]int) string {
+	if len(kinds) == 0 {
+		return ""
+	}
+	var names []string
+	for k := range kinds {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, k := range names {
+		parts[i] = fmt.Sprintf("%s %d", k, kinds[k])
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
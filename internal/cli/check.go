@@ -4,13 +4,19 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/emorilebo/go_dep_audit/pkg/audit"
 	"github.com/spf13/cobra"
 )
 
 var (
-	failThreshold int
+	failThreshold    int
+	maxExclusiveDeps int
+	buildOnly        bool
+	failOnOutdated   string
+	maxLibyears      float64
+	failOnRetracted  bool
 )
 
 var checkCmd = &cobra.Command{
@@ -21,28 +27,84 @@ var checkCmd = &cobra.Command{
 
 func init() {
 	checkCmd.Flags().IntVar(&failThreshold, "fail-threshold", 50, "Fail if any module score is below this threshold")
+	checkCmd.Flags().IntVar(&maxExclusiveDeps, "max-exclusive-deps", 0, "Fail if any module alone pulls in more than this many deps (0 disables)")
+	checkCmd.Flags().StringVar(&failOnOutdated, "fail-on-outdated", "", "Fail on modules with a newer version: major, minor or patch")
+	checkCmd.Flags().Float64Var(&maxLibyears, "max-libyears", 0, "Fail if the dependencies are more than this many libyears behind in total (0 disables)")
+	checkCmd.Flags().BoolVar(&failOnRetracted, "fail-on-retracted", false, "Fail if any module version is retracted by its author")
+	checkCmd.Flags().BoolVar(&buildOnly, "build-only", false, "Only check modules linked into binaries, ignoring test-only and graph-only modules")
+	addProjectFlags(checkCmd)
 }
 
 func runCheck(cmd *cobra.Command, args []string) error {
-	config := audit.AuditConfig{
-		ProjectPath: projectPath,
-		Scoring:     audit.DefaultScoringConfig(),
-	}
-
-	results, err := audit.AuditModules(context.Background(), config)
+	config, err := newAuditConfig()
 	if err != nil {
 		return err
 	}
+	config.BuildOnly = buildOnly
+	switch failOnOutdated {
+	case "", "major", "minor", "patch":
+	default:
+		return fmt.Errorf("invalid --fail-on-outdated %q, want major, minor or patch", failOnOutdated)
+	}
 
+	var results []audit.ModuleHealth
 	failed := false
+	if recursive {
+		multi, err := audit.AuditRecursive(context.Background(), config)
+		if err != nil {
+			return err
+		}
+		for _, p := range multi.Projects {
+			if p.Error != "" {
+				fmt.Printf("FAIL: %s could not be audited: %s\n", p.ProjectPath, p.Error)
+				failed = true
+			}
+		}
+		results = multi.Rollup
+	} else {
+		results, err = audit.AuditModules(context.Background(), config)
+		if err != nil {
+			return err
+		}
+	}
+
+	unknown := 0
 	for _, res := range results {
-		if res.HealthScore < failThreshold {
-			fmt.Printf("FAIL: %s@%s (Score: %d) is below threshold %d\n", 
-				res.Path, res.Version, res.HealthScore, failThreshold)
+		if res.HealthCategory == audit.Unknown {
+			// Nothing to score it on, e.g. offline without its metadata
+			unknown++
+		} else if res.HealthScore < failThreshold {
+			fmt.Printf("FAIL: %s@%s (Score: %d) is below threshold %d%s\n",
+				res.Path, res.Version, res.HealthScore, failThreshold, requiredBySuffix(res))
+			failed = true
+		}
+		if maxExclusiveDeps > 0 && res.ExclusiveDeps > maxExclusiveDeps {
+			fmt.Printf("FAIL: %s@%s pulls in %d exclusive deps (%d transitive, depth %d), limit is %d%s\n",
+				res.Path, res.Version, res.ExclusiveDeps, res.TransitiveDeps, res.MaxDepth, maxExclusiveDeps, requiredBySuffix(res))
+			failed = true
+		}
+		if failOnRetracted && res.Retracted {
+			fmt.Printf("FAIL: %s@%s is retracted: %s%s\n", res.Path, res.Version, retraction(res), requiredBySuffix(res))
+			failed = true
+		}
+		if reason := outdatedFailure(res); reason != "" {
+			fmt.Printf("FAIL: %s@%s is outdated: %s%s\n", res.Path, res.Version, reason, requiredBySuffix(res))
 			failed = true
 		}
 	}
 
+	if maxLibyears > 0 {
+		if summary := audit.SummarizeLibyears(results); summary.Total > maxLibyears {
+			fmt.Printf("FAIL: dependencies are %.2f libyears behind (direct %.2f, indirect %.2f), budget is %.2f\n",
+				summary.Total, summary.Direct, summary.Indirect, maxLibyears)
+			failed = true
+		}
+	}
+
+	if unknown > 0 {
+		fmt.Printf("Warning: could not score %s, not checked against the threshold\n", plural(unknown, "module"))
+	}
+
 	if failed {
 		os.Exit(1)
 	}
@@ -50,3 +112,42 @@ func runCheck(cmd *cobra.Command, args []string) error {
 	fmt.Println("All checks passed.")
 	return nil
 }
+
+// requiredBySuffix names the main modules behind a failure, if any
+func requiredBySuffix(res audit.ModuleHealth) string {
+	if len(res.RequiredBy) == 0 {
+		return ""
+	}
+	return " (required by " + requiredBy(res) + ")"
+}
+
+// outdatedFailure explains why res fails --fail-on-outdated, or returns ""
+func outdatedFailure(res audit.ModuleHealth) string {
+	newerMajor := res.NewerMajor != ""
+	switch failOnOutdated {
+	case "major":
+		if newerMajor {
+			return outdatedReason(res)
+		}
+	case "minor":
+		if newerMajor || res.MinorsBehind > 0 {
+			return outdatedReason(res)
+		}
+	case "patch":
+		if res.Outdated() {
+			return outdatedReason(res)
+		}
+	}
+	return ""
+}
+
+func outdatedReason(res audit.ModuleHealth) string {
+	var parts []string
+	if b := behind(res); b != "-" {
+		parts = append(parts, "behind by "+b)
+	}
+	if res.NewerMajor != "" {
+		parts = append(parts, "newer major version "+res.NewerMajor)
+	}
+	return strings.Join(parts, ", ")
+}
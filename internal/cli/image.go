@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/emorilebo/go_dep_audit/pkg/audit"
+	"github.com/spf13/cobra"
+)
+
+var imageCmd = &cobra.Command{
+	Use:   "image <path>",
+	Short: "Audit the Go binaries in a container image",
+	Long: `Walks the layers of a local OCI image layout directory or a 'docker save'
+tarball, reads the build info of every Go binary and audits it. Results are
+shown per binary and combined for the whole image.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImage,
+}
+
+func init() {
+	imageCmd.Flags().StringVar(&outputJSON, "output-json", "", "Path to save JSON report")
+	imageCmd.Flags().StringVar(&outputMD, "output-md", "", "Path to save Markdown report")
+}
+
+func runImage(cmd *cobra.Command, args []string) error {
+	config, err := newAuditConfig()
+	if err != nil {
+		return err
+	}
+	// Build info has no indirect markers, every listed module is linked in
+	config.IncludeIndirect = true
+
+	result, err := audit.AuditImage(context.Background(), config, args[0])
+	if err != nil {
+		return err
+	}
+
+	if outputJSON == "" && outputMD == "" {
+		if len(result.Binaries) == 0 {
+			fmt.Printf("No Go binaries found in %s\n", args[0])
+			return nil
+		}
+		for _, b := range result.Binaries {
+			fmt.Printf("\n=== %s (%s) ===\n", b.Path, binaryDescription(b))
+			printScanSummary(b.Results)
+		}
+		fmt.Printf("\n=== Combined (%d binaries) ===\n", len(result.Binaries))
+		printScanSummary(result.Rollup)
+		return nil
+	}
+
+	return writeReports(result, func(file io.Writer) {
+		fmt.Fprintln(file, "# Dependency Audit Report")
+		fmt.Fprintln(file, "")
+		fmt.Fprintf(file, "Image: `%s`\n", args[0])
+		for _, b := range result.Binaries {
+			fmt.Fprintln(file, "")
+			fmt.Fprintf(file, "## %s\n\n", b.Path)
+			fmt.Fprintf(file, "%s\n\n", binaryDescription(b))
+			writeMarkdownTable(file, b.Results)
+		}
+		fmt.Fprintln(file, "")
+		fmt.Fprintf(file, "## Combined (%d binaries)\n\n", len(result.Binaries))
+		writeMarkdownTable(file, result.Rollup)
+	})
+}
+
+// binaryDescription renders the main module, Go version and platform
+func binaryDescription(b audit.BinaryAudit) string {
+	desc := fmt.Sprintf("main module %s %s, %s", b.MainModule, b.MainVersion, b.GoVersion)
+	if b.Platform != "" {
+		desc += ", " + b.Platform
+	}
+	return desc
+}
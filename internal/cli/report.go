@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/emorilebo/go_dep_audit/pkg/audit"
@@ -24,12 +25,36 @@ var reportCmd = &cobra.Command{
 func init() {
 	reportCmd.Flags().StringVar(&outputJSON, "output-json", "", "Path to save JSON report")
 	reportCmd.Flags().StringVar(&outputMD, "output-md", "", "Path to save Markdown report")
+	addProjectFlags(reportCmd)
 }
 
 func runReport(cmd *cobra.Command, args []string) error {
-	config := audit.AuditConfig{
-		ProjectPath: projectPath,
-		Scoring:     audit.DefaultScoringConfig(),
+	config, err := newAuditConfig()
+	if err != nil {
+		return err
+	}
+
+	if recursive {
+		multi, err := audit.AuditRecursive(context.Background(), config)
+		if err != nil {
+			return err
+		}
+		return writeReports(multi, func(file io.Writer) {
+			fmt.Fprintln(file, "# Dependency Audit Report")
+			for _, p := range multi.Projects {
+				fmt.Fprintln(file, "")
+				fmt.Fprintf(file, "## %s\n\n", p.Module)
+				fmt.Fprintf(file, "Path: `%s`\n\n", p.ProjectPath)
+				if p.Error != "" {
+					fmt.Fprintf(file, "Audit failed: %s\n", p.Error)
+					continue
+				}
+				writeMarkdownTable(file, p.Results)
+			}
+			fmt.Fprintln(file, "")
+			fmt.Fprintf(file, "## Combined (%d modules)\n\n", len(multi.Projects))
+			writeMarkdownTable(file, multi.Rollup)
+		})
 	}
 
 	results, err := audit.AuditModules(context.Background(), config)
@@ -37,15 +62,25 @@ func runReport(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	return writeReports(results, func(file io.Writer) {
+		fmt.Fprintln(file, "# Dependency Audit Report")
+		fmt.Fprintln(file, "")
+		writeMarkdownTable(file, results)
+	})
+}
+
+// writeReports saves v as JSON and renders the Markdown report as requested
+// by the flags, defaulting to JSON on stdout
+func writeReports(v interface{}, markdown func(io.Writer)) error {
 	if outputJSON != "" {
-		if err := generateJSONReport(results, outputJSON); err != nil {
+		if err := generateJSONReport(v, outputJSON); err != nil {
 			return err
 		}
 		fmt.Printf("JSON report saved to %s\n", outputJSON)
 	}
 
 	if outputMD != "" {
-		if err := generateMarkdownReport(results, outputMD); err != nil {
+		if err := generateMarkdownReport(outputMD, markdown); err != nil {
 			return err
 		}
 		fmt.Printf("Markdown report saved to %s\n", outputMD)
@@ -55,13 +90,13 @@ func runReport(cmd *cobra.Command, args []string) error {
 		// Default to JSON to stdout
 		enc := json.NewEncoder(os.Stdout)
 		enc.SetIndent("", "  ")
-		return enc.Encode(results)
+		return enc.Encode(v)
 	}
 
 	return nil
 }
 
-func generateJSONReport(results []audit.ModuleHealth, path string) error {
+func generateJSONReport(v interface{}, path string) error {
 	file, err := os.Create(path)
 	if err != nil {
 		return err
@@ -70,25 +105,60 @@ func generateJSONReport(results []audit.ModuleHealth, path string) error {
 
 	enc := json.NewEncoder(file)
 	enc.SetIndent("", "  ")
-	return enc.Encode(results)
+	return enc.Encode(v)
 }
 
-func generateMarkdownReport(results []audit.ModuleHealth, path string) error {
+func generateMarkdownReport(path string, render func(io.Writer)) error {
 	file, err := os.Create(path)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
-	fmt.Fprintln(file, "# Dependency Audit Report")
+	render(file)
+	return nil
+}
+
+// writeMarkdownTable renders one row per module
+func writeMarkdownTable(file io.Writer, results []audit.ModuleHealth) {
+	fmt.Fprintln(file, "| Module | Version | Score | Category | License | Used In | Latest | Libyears | Deps | Exclusive | Depth | Required By |")
+	fmt.Fprintln(file, "|--------|---------|-------|----------|---------|---------|--------|----------|------|-----------|-------|-------------|")
+
+	for _, res := range results {
+		fmt.Fprintf(file, "| %s | %s | %d | %s | %s | %s | %s | %.2f | %d | %d | %d | %s |\n",
+			res.Path, res.Version, res.HealthScore, res.HealthCategory, res.License, usedIn(res),
+			orDash(res.LatestVersion), res.Libyear, res.TransitiveDeps, res.ExclusiveDeps, res.MaxDepth, requiredBy(res))
+	}
+
 	fmt.Fprintln(file, "")
-	fmt.Fprintln(file, "| Module | Version | Score | Category | License |")
-	fmt.Fprintln(file, "|--------|---------|-------|----------|---------|")
-	
+	fmt.Fprintf(file, "Libyears: %s\n", libyearSummary(audit.SummarizeLibyears(results)))
+
+	header := false
+	for _, res := range results {
+		reason := abandoned(res)
+		if reason == "" {
+			continue
+		}
+		if !header {
+			fmt.Fprintln(file, "")
+			fmt.Fprintln(file, "Archived or deprecated modules:")
+			fmt.Fprintln(file, "")
+			header = true
+		}
+		fmt.Fprintf(file, "- %s@%s: %s\n", res.Path, res.Version, reason)
+	}
+
+	header = false
 	for _, res := range results {
-		fmt.Fprintf(file, "| %s | %s | %d | %s | %s |\n", 
-			res.Path, res.Version, res.HealthScore, res.HealthCategory, res.License)
+		if !res.Retracted {
+			continue
+		}
+		if !header {
+			fmt.Fprintln(file, "")
+			fmt.Fprintln(file, "Retracted versions:")
+			fmt.Fprintln(file, "")
+			header = true
+		}
+		fmt.Fprintf(file, "- %s@%s: %s\n", res.Path, res.Version, retraction(res))
 	}
-	
-	return nil
 }
@@ -1,6 +1,13 @@
 package cli
 
 import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emorilebo/go_dep_audit/pkg/audit"
 	"github.com/spf13/cobra"
 )
 
@@ -8,6 +15,21 @@ var (
 	projectPath   string
 	configFile    string
 	verboseOutput bool
+	repoMetadata  bool
+	forges        []string
+	cacheDir      string
+	cacheTTL      time.Duration
+	noCache       bool
+	rateLimits    []string
+	maxRetries    int
+	offline       bool
+
+	// Flags shared by the project-based commands
+	recursive       bool
+	useVendor       bool
+	reachability    bool
+	platforms       []string
+	includeIndirect bool
 )
 
 var rootCmd = &cobra.Command{
@@ -27,8 +49,82 @@ func init() {
 	rootCmd.PersistentFlags().StringVarP(&projectPath, "project-path", "p", ".", "Path to the Go project to audit")
 	rootCmd.PersistentFlags().StringVarP(&configFile, "config", "c", "", "Path to configuration file")
 	rootCmd.PersistentFlags().BoolVarP(&verboseOutput, "verbose", "v", false, "Enable verbose output")
+	rootCmd.PersistentFlags().BoolVar(&repoMetadata, "repo-metadata", false, "Fetch stars, contributors and commit activity from the code forge (uses GITHUB_TOKEN and GITLAB_TOKEN)")
+	rootCmd.PersistentFlags().StringVar(&cacheDir, "cache-dir", audit.DefaultCacheDir(), "Directory to keep fetched metadata in between runs")
+	rootCmd.PersistentFlags().DurationVar(&cacheTTL, "cache-ttl", audit.DefaultCacheTTL, "How long cached version lists and repository metadata stay fresh")
+	rootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "Fetch everything again instead of using the metadata cache")
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", false, "Never touch the network: read metadata from the cache, file:// proxies and GOMODCACHE/cache/download")
+	rootCmd.PersistentFlags().StringArrayVar(&rateLimits, "rate-limit", nil, "Send at most this many requests per second to a host, as host=rps (repeatable)")
+	rootCmd.PersistentFlags().IntVar(&maxRetries, "max-retries", audit.DefaultMaxRetries, "Retry failed requests this many times, with backoff (0 disables)")
+	rootCmd.PersistentFlags().StringArrayVar(&forges, "forge", nil, "Register a self-hosted forge as host=type[,baseURL]; type is github, gitlab, gitea or bitbucket (repeatable)")
 
 	rootCmd.AddCommand(scanCmd)
 	rootCmd.AddCommand(reportCmd)
 	rootCmd.AddCommand(checkCmd)
+	rootCmd.AddCommand(binaryCmd)
+	rootCmd.AddCommand(imageCmd)
+}
+
+// addProjectFlags registers the flags shared by scan, report and check
+func addProjectFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "Audit every go.mod found under the project path")
+	cmd.Flags().BoolVar(&useVendor, "vendor", false, "Audit vendor/modules.txt offline, reading licenses from the vendored sources")
+	cmd.Flags().BoolVar(&reachability, "reachability", false, "Analyze packages to tell modules linked into binaries from test-only and graph-only ones")
+	cmd.Flags().StringArrayVar(&platforms, "platform", nil, "Analyze reachability for GOOS/GOARCH[:tags] (repeatable; the cgo tag enables cgo); only annotates results unless --build-only is set")
+	cmd.Flags().BoolVar(&includeIndirect, "include-indirect", false, "Also audit indirect dependencies, counting them in the libyear totals and checks")
+}
+
+// newAuditConfig builds the audit configuration from the command line flags
+func newAuditConfig() (audit.AuditConfig, error) {
+	config := audit.AuditConfig{
+		ProjectPath: projectPath,
+		Scoring:     audit.DefaultScoringConfig(),
+		Vendor:      useVendor,
+		CacheDir:    cacheDir,
+		CacheTTL:    cacheTTL,
+
+		IncludeIndirect: includeIndirect,
+
+		FetchRepoMetadata: repoMetadata,
+		GitHubToken:       os.Getenv("GITHUB_TOKEN"),
+		GitLabToken:       os.Getenv("GITLAB_TOKEN"),
+
+		AnalyzeReachability: reachability,
+		Offline:             offline,
+		MaxRetries:          maxRetries,
+		Verbose:             verboseOutput,
+		// Load other defaults or from config file
+	}
+	if noCache {
+		config.CacheDir = ""
+	}
+	if maxRetries == 0 {
+		config.MaxRetries = -1
+	}
+	for _, l := range rateLimits {
+		host, rps, ok := strings.Cut(l, "=")
+		limit, err := strconv.ParseFloat(rps, 64)
+		if !ok || host == "" || err != nil || limit < 0 {
+			return config, fmt.Errorf("invalid --rate-limit %q, want host=requests per second", l)
+		}
+		if config.RateLimits == nil {
+			config.RateLimits = make(map[string]float64)
+		}
+		config.RateLimits[host] = limit
+	}
+	for _, p := range platforms {
+		target, err := audit.ParseBuildTarget(p)
+		if err != nil {
+			return config, err
+		}
+		config.Platforms = append(config.Platforms, target)
+	}
+	for _, f := range forges {
+		forge, err := audit.ParseForge(f)
+		if err != nil {
+			return config, err
+		}
+		config.Forges = append(config.Forges, forge)
+	}
+	return config, nil
 }
@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emorilebo/go_dep_audit/pkg/audit"
+)
+
+func TestIncludeIndirectLibyears(t *testing.T) {
+	files := map[string]string{
+		"/example.com/direct/@v/list":          "v1.0.0\n",
+		"/example.com/direct/@v/v1.0.0.info":   `{"Version":"v1.0.0","Time":"2024-01-01T00:00:00Z"}`,
+		"/example.com/direct/@v/v1.0.0.mod":    "module example.com/direct\n\ngo 1.16\n",
+		"/example.com/indirect/@v/list":        "v1.0.0\nv1.1.0\n",
+		"/example.com/indirect/@v/v1.0.0.info": `{"Version":"v1.0.0","Time":"2020-01-01T00:00:00Z"}`,
+		"/example.com/indirect/@v/v1.1.0.info": `{"Version":"v1.1.0","Time":"2022-01-01T00:00:00Z"}`,
+		"/example.com/indirect/@v/v1.1.0.mod":  "module example.com/indirect\n\ngo 1.16\n",
+		"/example.com/indirect/@latest":        `{"Version":"v1.1.0","Time":"2022-01-01T00:00:00Z"}`,
+		"/example.com/direct/@latest":          `{"Version":"v1.0.0","Time":"2024-01-01T00:00:00Z"}`,
+		"/example.com/indirect/@v/v1.0.0.mod":  "module example.com/indirect\n\ngo 1.16\n",
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(data))
+	}))
+	defer srv.Close()
+	t.Setenv("GOPROXY", srv.URL)
+	t.Setenv("GOMODCACHE", t.TempDir())
+
+	if err := scanCmd.Flags().Set("include-indirect", "true"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { includeIndirect = false })
+	noCache = true
+	t.Cleanup(func() { noCache = false })
+
+	config, err := newAuditConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	modules := []audit.Module{
+		{Path: "example.com/direct", Version: "v1.0.0"},
+		{Path: "example.com/indirect", Version: "v1.0.0", Indirect: true},
+	}
+	summary := audit.SummarizeLibyears(audit.AuditModuleList(context.Background(), config, modules))
+	if summary.Indirect < 1.9 || summary.Direct != 0 {
+		t.Errorf("SummarizeLibyears() = %+v, want about 2 indirect libyears", summary)
+	}
+	if got := libyearSummary(summary); !strings.Contains(got, "indirect 2.00") {
+		t.Errorf("libyearSummary() = %q", got)
+	}
+}
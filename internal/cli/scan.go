@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sort"
+	"strings"
 	"text/tabwriter"
 
 	"github.com/emorilebo/go_dep_audit/pkg/audit"
@@ -16,20 +18,47 @@ var scanCmd = &cobra.Command{
 	RunE:  runScan,
 }
 
+func init() {
+	addProjectFlags(scanCmd)
+}
+
 func runScan(cmd *cobra.Command, args []string) error {
-	config := audit.AuditConfig{
-		ProjectPath: projectPath,
-		Scoring:     audit.DefaultScoringConfig(),
-		// Load other defaults or from config file
+	config, err := newAuditConfig()
+	if err != nil {
+		return err
 	}
 
 	fmt.Printf("Scanning dependencies in %s...\n", projectPath)
-	
+
+	if recursive {
+		multi, err := audit.AuditRecursive(context.Background(), config)
+		if err != nil {
+			return err
+		}
+		for _, p := range multi.Projects {
+			fmt.Printf("\n=== %s (%s) ===\n", p.Module, p.ProjectPath)
+			if p.Error != "" {
+				fmt.Printf("Error: %s\n", p.Error)
+				continue
+			}
+			printScanSummary(p.Results)
+		}
+		fmt.Printf("\n=== Combined (%d modules) ===\n", len(multi.Projects))
+		printScanSummary(multi.Rollup)
+		return nil
+	}
+
 	results, err := audit.AuditModules(context.Background(), config)
 	if err != nil {
 		return err
 	}
 
+	printScanSummary(results)
+	return nil
+}
+
+// printScanSummary prints category counts and the modules that need attention
+func printScanSummary(results []audit.ModuleHealth) {
 	// Summary counts
 	counts := make(map[audit.HealthCategory]int)
 	for _, res := range results {
@@ -42,18 +71,190 @@ func runScan(cmd *cobra.Command, args []string) error {
 	fmt.Printf("Warning: %d\n", counts[audit.Warning])
 	fmt.Printf("Stale:   %d\n", counts[audit.Stale])
 	fmt.Printf("Risky:   %d\n", counts[audit.Risky])
+	if counts[audit.Unknown] > 0 {
+		fmt.Printf("Unknown: %d\n", counts[audit.Unknown])
+	}
+	fmt.Printf("Libyears: %s\n", libyearSummary(audit.SummarizeLibyears(results)))
+
+	// Modules scored without metadata, e.g. private modules without credentials
+	var unavailable []audit.ModuleHealth
+	for _, res := range results {
+		if res.MetadataError != "" {
+			unavailable = append(unavailable, res)
+		}
+	}
+	if len(unavailable) > 0 {
+		fmt.Printf("\nWarning: metadata unavailable for %s, their health is unknown (%s: %s)\n",
+			plural(len(unavailable), "module"), unavailable[0].Path, unavailable[0].MetadataError)
+	}
+
+	// Only shown when packages were analyzed
+	reach := make(map[audit.Reachability]int)
+	for _, res := range results {
+		reach[res.Reachability]++
+	}
+	if reach[audit.ReachabilityUnknown] < len(results) {
+		fmt.Printf("\nLinked: %d, Test-only: %d, Graph-only: %d\n",
+			reach[audit.ReachabilityBuild], reach[audit.ReachabilityTest], reach[audit.ReachabilityGraph])
+	}
 
 	if counts[audit.Risky] > 0 || counts[audit.Stale] > 0 {
 		fmt.Println("\nRisky/Stale Modules:")
 		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "Module\tVersion\tScore\tCategory\tLicense")
+		fmt.Fprintln(w, "Module\tVersion\tScore\tCategory\tLicense\tUsed In\tDeps\tRequired By")
 		for _, res := range results {
 			if res.HealthCategory == audit.Risky || res.HealthCategory == audit.Stale {
-				fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", res.Path, res.Version, res.HealthScore, res.HealthCategory, res.License)
+				fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\t%d\t%s\n", res.Path, res.Version, res.HealthScore, res.HealthCategory, res.License, usedIn(res), res.TransitiveDeps, requiredBy(res))
 			}
 		}
 		w.Flush()
 	}
 
-	return nil
+	// Modules that are Risky because upstream gave up on them
+	var abandonedModules []audit.ModuleHealth
+	for _, res := range results {
+		if abandoned(res) != "" {
+			abandonedModules = append(abandonedModules, res)
+		}
+	}
+	if len(abandonedModules) > 0 {
+		fmt.Printf("\nArchived/Deprecated Modules (%d):\n", len(abandonedModules))
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Module\tVersion\tReason")
+		for _, res := range abandonedModules {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", res.Path, res.Version, abandoned(res))
+		}
+		w.Flush()
+	}
+
+	// Versions their authors retracted
+	var retracted []audit.ModuleHealth
+	for _, res := range results {
+		if res.Retracted {
+			retracted = append(retracted, res)
+		}
+	}
+	if len(retracted) > 0 {
+		fmt.Printf("\nRetracted Versions (%d):\n", len(retracted))
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Module\tVersion\tLatest\tRationale")
+		for _, res := range retracted {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", res.Path, res.Version, orDash(res.LatestVersion), retraction(res))
+		}
+		w.Flush()
+	}
+
+	// Modules with newer versions available
+	var outdated []audit.ModuleHealth
+	for _, res := range results {
+		if res.Outdated() {
+			outdated = append(outdated, res)
+		}
+	}
+	if len(outdated) > 0 {
+		fmt.Printf("\nOutdated Dependencies (%d):\n", len(outdated))
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Module\tVersion\tLatest\tBehind\tLibyears\tNewer Major")
+		for _, res := range outdated {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%.2f\t%s\n", res.Path, res.Version, res.LatestVersion, behind(res), res.Libyear, orDash(res.NewerMajor))
+		}
+		w.Flush()
+	}
+
+	// Heaviest modules by the number of deps that exist only because of them
+	heavy := make([]audit.ModuleHealth, 0, len(results))
+	for _, res := range results {
+		if res.ExclusiveDeps > 0 {
+			heavy = append(heavy, res)
+		}
+	}
+	if len(heavy) > 0 {
+		sort.Slice(heavy, func(i, j int) bool { return heavy[i].ExclusiveDeps > heavy[j].ExclusiveDeps })
+		if len(heavy) > 5 {
+			heavy = heavy[:5]
+		}
+		fmt.Println("\nHeaviest Dependencies:")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Module\tTransitive\tExclusive\tDepth\tFootprint Risk")
+		for _, res := range heavy {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%.1f\n", res.Path, res.TransitiveDeps, res.ExclusiveDeps, res.MaxDepth, res.FootprintRisk)
+		}
+		w.Flush()
+	}
+}
+
+// requiredBy renders the main modules a dependency is attributed to
+func requiredBy(res audit.ModuleHealth) string {
+	if len(res.RequiredBy) == 0 {
+		return "-"
+	}
+	return strings.Join(res.RequiredBy, ", ")
+}
+
+// usedIn renders the reachability of a module and where it is linked
+func usedIn(res audit.ModuleHealth) string {
+	if len(res.Platforms) == 0 {
+		return res.Reachability.String()
+	}
+	return res.Reachability.String() + " (" + strings.Join(res.Platforms, ", ") + ")"
+}
+
+// abandoned explains why a module counts as abandoned, or returns ""
+func abandoned(res audit.ModuleHealth) string {
+	if res.Metadata == nil {
+		return ""
+	}
+	var parts []string
+	if res.Metadata.Deprecated != "" {
+		parts = append(parts, "deprecated: "+res.Metadata.Deprecated)
+	}
+	if res.Metadata.Archived {
+		parts = append(parts, "repository archived")
+	}
+	return strings.Join(parts, "; ")
+}
+
+// retraction renders the rationale of a retracted version
+func retraction(res audit.ModuleHealth) string {
+	if res.Retraction == "" {
+		return "no rationale given"
+	}
+	return res.Retraction
+}
+
+// behind renders how many minor and patch releases a module is behind
+func behind(res audit.ModuleHealth) string {
+	var parts []string
+	if res.MinorsBehind > 0 {
+		parts = append(parts, plural(res.MinorsBehind, "minor"))
+	}
+	if res.PatchesBehind > 0 {
+		parts = append(parts, plural(res.PatchesBehind, "patch"))
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return "1 " + word
+	}
+	if strings.HasSuffix(word, "ch") {
+		return fmt.Sprintf("%d %ses", n, word)
+	}
+	return fmt.Sprintf("%d %ss", n, word)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// libyearSummary renders the libyear total with its direct/indirect split
+func libyearSummary(s audit.LibyearSummary) string {
+	return fmt.Sprintf("%.2f (direct %.2f, indirect %.2f)", s.Total, s.Direct, s.Indirect)
 }
@@ -3,23 +3,52 @@ package audit
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"sync"
 )
 
-// AuditModules performs a full audit of the project's dependencies
+// AuditModules performs a full audit of the project's dependencies. When the
+// project is a go.work workspace, all of its modules are audited together:
+// shared dependencies are audited once and attributed to every workspace
+// module that pulls them in.
 func AuditModules(ctx context.Context, config AuditConfig) ([]ModuleHealth, error) {
+	return auditProject(ctx, config, auditFetcher(config))
+}
+
+// auditProject is AuditModules with a caller-provided Fetcher, so several
+// projects can share fetched metadata
+func auditProject(ctx context.Context, config AuditConfig, fetcher *Fetcher) ([]ModuleHealth, error) {
 	// 1. Get Dependency Graph
-	modules, err := GetModuleGraph(ctx, config.ProjectPath)
+	modules, graph, err := loadModules(ctx, config, fetcher)
 	if err != nil {
-		// Fallback to parsing go.mod if go list fails (e.g. no go installed)
-		// This is critical for the agent environment where go might be missing
-		fmt.Printf("Warning: 'go list' failed (%v), falling back to simple go.mod parsing\n", err)
-		modules, err = ParseGoMod(config.ProjectPath + "/go.mod")
+		return nil, err
+	}
+
+	// Which modules actually get linked needs the packages, not just go.mod
+	var reach *ReachabilityAnalysis
+	if config.AnalyzeReachability || config.BuildOnly || len(config.Platforms) > 0 {
+		reach, err = analyzeReachability(ctx, config.ProjectPath, config.Platforms, goCommandEnvFor(config))
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse modules: %w", err)
+			fmt.Fprintf(os.Stderr, "Warning: package analysis failed (%v), reachability is unknown\n", err)
 		}
 	}
 
+	return auditModuleList(ctx, config, fetcher, modules, graph, reach), nil
+}
+
+// AuditModuleList scores and license-checks an already known module list,
+// e.g. one read from a binary's build info, the same way AuditModules does
+// for a project. Main modules are skipped. Without a requirement graph the
+// footprint metrics stay zero. Every module is taken to be linked in, as is
+// the case for build info.
+func AuditModuleList(ctx context.Context, config AuditConfig, modules []Module) []ModuleHealth {
+	return auditModuleList(ctx, config, auditFetcher(config), modules, nil, linkedReachability(modules, ""))
+}
+
+// auditModuleList audits modules in parallel. reach is the package analysis
+// result, nil when none was done.
+func auditModuleList(ctx context.Context, config AuditConfig, fetcher *Fetcher, modules []Module, graph *DependencyGraph, reach *ReachabilityAnalysis) []ModuleHealth {
 	// Filter modules based on config
 	var targetModules []Module
 	for _, m := range modules {
@@ -43,12 +72,18 @@ func AuditModules(ctx context.Context, config AuditConfig) ([]ModuleHealth, erro
 		if !config.IncludeIndirect && m.Indirect {
 			continue
 		}
+
+		// Modules that are known not to be linked into binaries. Platforms
+		// alone only annotate the results.
+		if r, _ := reach.of(m.Path); config.BuildOnly && (r == ReachabilityTest || r == ReachabilityGraph) {
+			continue
+		}
 		targetModules = append(targetModules, m)
 	}
 
 	// 2. Fetch Metadata and Score (Parallel)
+	source := metadataSource(config, fetcher)
 	results := make([]ModuleHealth, len(targetModules))
-	fetcher := NewFetcher(config)
 	
 	var wg sync.WaitGroup
 	semaphore := make(chan struct{}, 10) // Limit concurrency
@@ -60,7 +95,7 @@ func AuditModules(ctx context.Context, config AuditConfig) ([]ModuleHealth, erro
 			semaphore <- struct{}{} // Acquire
 			defer func() { <-semaphore }() // Release
 
-			health, err := auditSingleModule(ctx, fetcher, m, config)
+			health, err := auditSingleModule(ctx, fetcher, source, m, graph, config)
 			if err != nil {
 				// Log error?
 				results[i] = ModuleHealth{
@@ -71,44 +106,226 @@ func AuditModules(ctx context.Context, config AuditConfig) ([]ModuleHealth, erro
 			} else {
 				results[i] = *health
 			}
+			results[i].Reachability, results[i].Platforms = reach.of(m.Path)
 		}(i, mod)
 	}
 	
 	wg.Wait()
+	fetcher.cache.saveRunStats()
+
+	// Attribute every finding to the workspace modules that require it
+	if graph != nil && len(graph.Roots) > 1 {
+		requiredBy := graph.RequiredBy()
+		for i := range results {
+			results[i].RequiredBy = requiredBy[results[i].Path]
+		}
+	}
+
+	return results
+}
+
+// moduleDir returns the directory holding the sources that get built for
+// mod, or "" when they are not on disk. Modules the go command downloaded
+// earlier are found in the module cache even when no Dir is known.
+func moduleDir(mod Module) string {
+	target := mod
+	if mod.Replace != nil {
+		if mod.Replace.Dir != "" {
+			return mod.Replace.Dir
+		}
+		target = *mod.Replace
+	}
+	if mod.Dir != "" {
+		return mod.Dir
+	}
+	if target.Version == "" {
+		return ""
+	}
+	if cache := goModCacheDir(); cache != "" {
+		if dir, err := modCacheSourceDir(cache, target.Path, target.Version); err == nil {
+			if _, err := os.Stat(dir); err == nil {
+				return dir
+			}
+		}
+	}
+	return ""
+}
+
+// loadModules returns the build list of the project or workspace and, when
+// it can be determined, the requirement graph
+func loadModules(ctx context.Context, config AuditConfig, fetcher *Fetcher) ([]Module, *DependencyGraph, error) {
+	if config.Vendor {
+		modules, err := LoadVendorModules(config.ProjectPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load vendored modules: %w", err)
+		}
+		return modules, nil, nil
+	}
 
-	return results, nil
+	gowork := FindGoWork(config.ProjectPath)
+
+	modules, err := getModuleGraph(ctx, config.ProjectPath, goCommandEnvFor(config))
+	if err == nil {
+		// The requirement graph is only needed for footprint metrics, so an
+		// audit without it is still useful
+		graph, err := getDependencyGraph(ctx, config.ProjectPath, goCommandEnvFor(config))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: 'go mod graph' failed (%v), footprint metrics are unavailable\n", err)
+		}
+		return modules, graph, nil
+	}
+
+	// Without a go toolchain (e.g. in minimal audit containers) resolve
+	// the build list ourselves from go.mod files and the module proxy
+	fmt.Fprintf(os.Stderr, "Warning: 'go list' failed (%v), resolving the module graph from go.mod files\n", err)
+	resolver := NewResolver(fetcher)
+	var graph *DependencyGraph
+	if gowork != "" {
+		modules, graph, err = resolver.ResolveWorkspace(ctx, gowork)
+	} else {
+		modules, graph, err = resolver.Resolve(ctx, config.ProjectPath)
+	}
+	if err == nil {
+		return modules, graph, nil
+	}
+
+	// Last resort: only the requirements listed in go.mod
+	fmt.Fprintf(os.Stderr, "Warning: module graph resolution failed (%v), falling back to simple go.mod parsing\n", err)
+	if gowork != "" {
+		modules, graph, err = parseWorkspaceGoMods(gowork)
+	} else {
+		modules, err = ParseGoMod(filepath.Join(config.ProjectPath, "go.mod"))
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse modules: %w", err)
+	}
+	return modules, graph, nil
 }
 
-func auditSingleModule(ctx context.Context, fetcher *Fetcher, mod Module, config AuditConfig) (*ModuleHealth, error) {
-	// Fetch metadata
-	meta, err := fetcher.FetchModuleMetadata(ctx, mod.Path, mod.Version)
+// parseWorkspaceGoMods merges the go.mod requirements of every workspace
+// module, keeping the highest required version of each dependency. The
+// returned graph only has the workspace modules' direct requirements, which
+// is still enough to attribute dependencies.
+func parseWorkspaceGoMods(gowork string) ([]Module, *DependencyGraph, error) {
+	work, err := ParseGoWorkFile(gowork)
 	if err != nil {
-		// If fetch fails, we proceed with partial info
-		meta = &ModuleMetadata{}
+		return nil, nil, err
+	}
+
+	var merged []Module
+	index := make(map[string]int)
+	graph := NewDependencyGraph()
+	for _, use := range work.Use {
+		mods, err := ParseGoMod(filepath.Join(resolveLocalPath(filepath.Dir(gowork), use), "go.mod"))
+		if err != nil {
+			return nil, nil, err
+		}
+		graph.Roots = append(graph.Roots, mods[0].Path)
+		for _, m := range mods[1:] {
+			graph.AddEdge(mods[0].Path, m.Path)
+		}
+		for _, m := range mods {
+			i, seen := index[m.Path]
+			switch {
+			case !seen:
+				index[m.Path] = len(merged)
+				merged = append(merged, m)
+			case m.Main:
+				merged[i] = m
+			case !merged[i].Main && compareVersions(m.Version, merged[i].Version) > 0:
+				m.Indirect = m.Indirect && merged[i].Indirect
+				merged[i] = m
+			default:
+				merged[i].Indirect = m.Indirect && merged[i].Indirect
+			}
+		}
+	}
+	for _, m := range merged {
+		graph.AddModule(m.Path, m.Version)
+	}
+	return merged, graph, nil
+}
+
+func auditSingleModule(ctx context.Context, fetcher *Fetcher, source MetadataSource, mod Module, graph *DependencyGraph, config AuditConfig) (*ModuleHealth, error) {
+	// Audit the code that actually gets built: a replaced module is looked
+	// up under its replacement path and version
+	target := mod
+	replacedBy := ""
+	if mod.Replace != nil {
+		target = *mod.Replace
+		replacedBy = ModuleVersion{Path: target.Path, Version: target.Version}.String()
+	}
+
+	// Fetch metadata. A local directory replacement has nothing to fetch,
+	// so nothing is known to score it on, and vendored modules are audited
+	// from their sources only.
+	meta := unknownMetadata()
+	metaErr := ""
+	switch {
+	case config.Vendor:
+		meta = vendorMetadata(target.Version)
+	case target.Version != "":
+		fetched, err := source.FetchModuleMetadata(ctx, target.Path, target.Version)
+		if err == nil {
+			meta = fetched
+		} else {
+			// If fetch fails, we proceed with partial info; nothing is
+			// known to score the module on
+			meta = unknownMetadata()
+			metaErr = err.Error()
+		}
 	}
 
 	// Calculate Score
 	score := CalculateHealthScore(meta, config.Scoring)
-	category := CategorizeHealth(score, config.Scoring)
+	category := CategorizeModule(meta, score, config.Scoring)
 
-	// License
-	license, _ := DetectLicense(ctx, mod.Path, mod.Version)
+	// License, read from the module's files when they are on disk
+	var license string
+	if dir := moduleDir(mod); dir != "" {
+		license, _ = DetectLicenseInDir(dir)
+	} else if zip := fetcher.moduleZip(target.Path, target.Version); zip != "" {
+		license, _ = DetectLicenseInZip(zip, target.Path, target.Version)
+	} else {
+		license, _ = DetectLicense(ctx, target.Path, target.Version)
+	}
 	licenseRisk := ClassifyLicense(license)
 
-	// Footprint (estimated)
-	// We don't have per-module footprint without graph analysis, so 0 for now
-	footprintRisk := 0.0
+	// Footprint
+	footprint := CalculateFootprint(mod, graph)
+
+	// Newer versions of what is built
+	status := &VersionStatus{}
+	if !config.Vendor && target.Version != "" {
+		if fetched, err := fetcher.FetchVersionStatus(ctx, target.Path, target.Version); err == nil {
+			status = fetched
+		}
+	}
 
 	return &ModuleHealth{
 		Path:           mod.Path,
 		Version:        mod.Version,
+		ReplacedBy:     replacedBy,
 		HealthScore:    score,
 		HealthCategory: category,
 		License:        license,
 		LicenseRisk:    licenseRisk,
-		FootprintRisk:  footprintRisk,
+		FootprintRisk:  CalculateFootprintRisk(footprint),
 		LastPublished:  meta.LastCommitDate,
+		TransitiveDeps: footprint.TransitiveDeps,
+		MaxDepth:       footprint.MaxDepth,
+		ExclusiveDeps:  footprint.ExclusiveDeps,
 		DirectDep:      !mod.Indirect,
+		LatestVersion:  status.Latest,
+		LatestMinor:    status.LatestMinor,
+		LatestPatch:    status.LatestPatch,
+		PatchesBehind:  status.PatchesBehind,
+		MinorsBehind:   status.MinorsBehind,
+		NewerMajor:     status.NewerMajor,
+		Libyear:        status.Libyear,
+		Retracted:      status.Retracted,
+		Retraction:     status.RetractionRationale,
+		MetadataError:  metaErr,
 		Metadata:       meta,
 	}, nil
 }
@@ -0,0 +1,31 @@
+package audit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAuditLocalReplacement(t *testing.T) {
+	t.Setenv("GOMODCACHE", t.TempDir())
+	local := t.TempDir()
+	if err := os.WriteFile(filepath.Join(local, "LICENSE"), []byte("Permission is hereby granted, free of charge, to any person obtaining a copy"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	config := AuditConfig{Scoring: DefaultScoringConfig(), Offline: true}
+	modules := []Module{{
+		Path:    "example.com/local",
+		Version: "v1.0.0",
+		Replace: &Module{Path: "../local", Dir: local},
+	}}
+
+	results := AuditModuleList(context.Background(), config, modules)
+	if len(results) != 1 {
+		t.Fatalf("got %d results", len(results))
+	}
+	res := results[0]
+	if res.HealthCategory != Unknown || res.ReplacedBy != "../local" || res.License != "MIT" {
+		t.Errorf("result = %+v, want an Unknown MIT module replaced by ../local", res)
+	}
+}
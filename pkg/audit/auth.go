@@ -0,0 +1,344 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// authTransport adds credentials to HTTPS requests: headers configured per
+// host, then GOAUTH helper commands, then ~/.netrc. Headers a request
+// already carries, such as forge API tokens, are left alone.
+type authTransport struct {
+	base http.RoundTripper
+
+	headers  map[string]http.Header
+	commands []*authCommand
+	netrc    []netrcMachine
+}
+
+// newAuthTransport reads the credential sources named by the config,
+// falling back to GOAUTH, which defaults to netrc like for the go command
+func newAuthTransport(config AuditConfig, base http.RoundTripper) *authTransport {
+	t := &authTransport{base: base, headers: make(map[string]http.Header)}
+	for host, header := range config.AuthHeaders {
+		h := http.Header{}
+		for k, v := range header {
+			h.Set(k, v)
+		}
+		t.headers[host] = h
+	}
+
+	for _, source := range strings.Split(firstNonEmpty(config.GoAuth, goEnv("GOAUTH"), "netrc"), ";") {
+		fields := strings.Fields(source)
+		switch {
+		case len(fields) == 0:
+		case fields[0] == "off":
+			t.commands, t.netrc = nil, nil
+			return t
+		case fields[0] == "netrc":
+			t.netrc = readNetrc()
+		case fields[0] == "git":
+			fmt.Fprintf(os.Stderr, "Warning: GOAUTH %q is not supported, skipping it\n", "git")
+		default:
+			t.commands = append(t.commands, &authCommand{args: fields})
+		}
+	}
+	return t
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Credentials never travel in the clear
+	if req.URL.Scheme != "https" {
+		return t.base.RoundTrip(req)
+	}
+
+	header := t.credentials(req.Context(), req.URL)
+	resp, err := t.base.RoundTrip(withHeaders(req, header))
+	if err != nil || (resp.StatusCode != http.StatusUnauthorized && resp.StatusCode != http.StatusForbidden) || req.Body != nil {
+		return resp, err
+	}
+
+	// Helpers get a chance to provide credentials for the rejected URL
+	refreshed := false
+	for _, c := range t.commands {
+		if c.refresh(req.Context(), req.URL, resp) {
+			refreshed = true
+		}
+	}
+	if !refreshed {
+		return resp, nil
+	}
+	retry := t.credentials(req.Context(), req.URL)
+	if headersEqual(retry, header) {
+		return resp, nil
+	}
+	resp.Body.Close()
+	return t.base.RoundTrip(withHeaders(req, retry))
+}
+
+// credentials returns the headers to send to u
+func (t *authTransport) credentials(ctx context.Context, u *url.URL) http.Header {
+	if h, ok := t.headers[u.Host]; ok {
+		return h
+	}
+	if h, ok := t.headers[u.Hostname()]; ok {
+		return h
+	}
+	for _, c := range t.commands {
+		if h := c.lookup(ctx, u); h != nil {
+			return h
+		}
+	}
+	if m, ok := lookupNetrc(t.netrc, u.Hostname()); ok {
+		h := http.Header{}
+		h.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(m.login+":"+m.password)))
+		return h
+	}
+	return nil
+}
+
+// withHeaders returns a copy of req with the headers it does not set yet
+func withHeaders(req *http.Request, header http.Header) *http.Request {
+	if len(header) == 0 {
+		return req
+	}
+	req = req.Clone(req.Context())
+	for k, vs := range header {
+		if req.Header.Get(k) == "" {
+			req.Header[k] = vs
+		}
+	}
+	return req
+}
+
+func headersEqual(a, b http.Header) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k := range a {
+		if strings.Join(a[k], "\n") != strings.Join(b[k], "\n") {
+			return false
+		}
+	}
+	return true
+}
+
+// authCommand is a GOAUTH helper command. It is run once up front, and
+// again with the URL as an extra argument and the response on stdin when
+// a server rejects a request.
+type authCommand struct {
+	args []string
+
+	once sync.Once
+	mu   sync.Mutex
+	sets []credentialSet
+}
+
+// credentialSet is one block of a GOAUTH command's output: the URL
+// prefixes the headers apply to
+type credentialSet struct {
+	prefixes []string
+	header   http.Header
+}
+
+func (c *authCommand) lookup(ctx context.Context, u *url.URL) http.Header {
+	c.once.Do(func() {
+		sets, err := c.run(ctx, nil, nil)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		c.sets = sets
+	})
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return matchCredentials(c.sets, u.String())
+}
+
+// refresh runs the command for a rejected request and reports whether it
+// returned credentials
+func (c *authCommand) refresh(ctx context.Context, u *url.URL, resp *http.Response) bool {
+	var stdin bytes.Buffer
+	fmt.Fprintf(&stdin, "%s %s\n", resp.Proto, resp.Status)
+	resp.Header.Write(&stdin)
+	stdin.WriteString("\n")
+
+	sets, err := c.run(ctx, []string{u.String()}, &stdin)
+	if err != nil || len(sets) == 0 {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Newer credentials take precedence
+	c.sets = append(sets, c.sets...)
+	return true
+}
+
+func (c *authCommand) run(ctx context.Context, extra []string, stdin *bytes.Buffer) ([]credentialSet, error) {
+	cmd := exec.CommandContext(ctx, c.args[0], append(c.args[1:], extra...)...)
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		// The output may hold secrets, only the command is named
+		return nil, fmt.Errorf("GOAUTH command %s failed: %v", c.args[0], err)
+	}
+	return parseAuthOutput(out), nil
+}
+
+// parseAuthOutput parses GOAUTH command output: blocks of https:// URL
+// lines, a blank line, header lines and another blank line
+func parseAuthOutput(out []byte) []credentialSet {
+	var sets []credentialSet
+	var cur credentialSet
+	inHeaders := false
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		switch {
+		case line == "" && !inHeaders:
+			inHeaders = len(cur.prefixes) > 0
+			cur.header = http.Header{}
+		case line == "":
+			if len(cur.header) > 0 {
+				sets = append(sets, cur)
+			}
+			cur, inHeaders = credentialSet{}, false
+		case inHeaders:
+			if k, v, ok := strings.Cut(line, ":"); ok {
+				cur.header.Add(strings.TrimSpace(k), strings.TrimSpace(v))
+			}
+		case strings.HasPrefix(line, "https://"):
+			cur.prefixes = append(cur.prefixes, line)
+		}
+	}
+	if inHeaders && len(cur.header) > 0 {
+		sets = append(sets, cur)
+	}
+	return sets
+}
+
+// matchCredentials returns the headers of the longest matching prefix
+func matchCredentials(sets []credentialSet, u string) http.Header {
+	var best http.Header
+	bestLen := -1
+	for _, s := range sets {
+		for _, p := range s.prefixes {
+			if urlHasPrefix(u, p) && len(p) > bestLen {
+				best, bestLen = s.header, len(p)
+			}
+		}
+	}
+	return best
+}
+
+// urlHasPrefix matches whole host and path elements, so
+// https://example.com/a does not match https://example.com/ab
+func urlHasPrefix(u, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return u == prefix || strings.HasPrefix(u, prefix+"/") || strings.HasPrefix(u, prefix+"?")
+}
+
+// netrcMachine is a machine entry of a .netrc file
+type netrcMachine struct {
+	name     string
+	login    string
+	password string
+}
+
+// readNetrc reads $NETRC, or .netrc (_netrc on Windows) in the home
+// directory
+func readNetrc() []netrcMachine {
+	path := os.Getenv("NETRC")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil
+		}
+		name := ".netrc"
+		if runtime.GOOS == "windows" {
+			name = "_netrc"
+		}
+		path = filepath.Join(home, name)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	return parseNetrc(string(data))
+}
+
+// parseNetrc parses the machine, login and password tokens of a .netrc
+// file, skipping macro definitions. Like the go command it ignores the
+// default entry, which would send its credentials to every host.
+func parseNetrc(data string) []netrcMachine {
+	var machines []netrcMachine
+	var cur *netrcMachine
+	lines := strings.Split(data, "\n")
+	for i := 0; i < len(lines); i++ {
+		fields := strings.Fields(lines[i])
+		for j := 0; j < len(fields); j++ {
+			switch fields[j] {
+			case "machine":
+				machines = append(machines, netrcMachine{})
+				cur = &machines[len(machines)-1]
+				if j+1 < len(fields) {
+					j++
+					cur.name = fields[j]
+				}
+			case "default":
+				cur = nil
+			case "login", "password", "account":
+				if j+1 >= len(fields) {
+					continue
+				}
+				j++
+				if cur == nil {
+					continue
+				}
+				if fields[j-1] == "login" {
+					cur.login = fields[j]
+				} else if fields[j-1] == "password" {
+					cur.password = fields[j]
+				}
+			case "macdef":
+				// The macro body runs until the next blank line
+				for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" {
+					i++
+				}
+				j = len(fields)
+			}
+		}
+	}
+	return machines
+}
+
+// lookupNetrc returns the entry for host
+func lookupNetrc(machines []netrcMachine, host string) (netrcMachine, bool) {
+	for _, m := range machines {
+		if m.name == host && m.login != "" {
+			return m, true
+		}
+	}
+	return netrcMachine{}, false
+}
+
+// redactURL hides the password of a URL with credentials, for messages
+func redactURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil || u.User == nil {
+		return s
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,46 @@
+package audit
+
+import (
+	"debug/buildinfo"
+	"fmt"
+	"runtime/debug"
+)
+
+// ReadBinaryModules returns the modules recorded in a Go binary's embedded
+// build info: the main module first, then every module linked into the
+// binary. Build info only lists modules that provide packages, so there is
+// no indirect information and every dependency is reported as direct.
+func ReadBinaryModules(path string) ([]Module, error) {
+	info, err := buildinfo.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read build info from %s: %w", path, err)
+	}
+	return ModulesFromBuildInfo(info), nil
+}
+
+// ModulesFromBuildInfo converts build info into a module list
+func ModulesFromBuildInfo(info *debug.BuildInfo) []Module {
+	modules := []Module{{
+		Path:    info.Main.Path,
+		Version: info.Main.Version,
+		Main:    true,
+	}}
+	for _, dep := range info.Deps {
+		modules = append(modules, moduleFromBuildInfo(dep))
+	}
+	return modules
+}
+
+func moduleFromBuildInfo(dep *debug.Module) Module {
+	mod := Module{
+		Path:    dep.Path,
+		Version: dep.Version,
+	}
+	if dep.Replace != nil {
+		mod.Replace = &Module{
+			Path:    dep.Replace.Path,
+			Version: dep.Replace.Version,
+		}
+	}
+	return mod
+}
@@ -0,0 +1,35 @@
+package audit
+
+import (
+	"runtime/debug"
+	"testing"
+)
+
+func TestModulesFromBuildInfo(t *testing.T) {
+	info := &debug.BuildInfo{
+		GoVersion: "go1.22.1",
+		Main:      debug.Module{Path: "example.com/app", Version: "(devel)"},
+		Deps: []*debug.Module{
+			{Path: "github.com/a/one", Version: "v1.2.0"},
+			{Path: "github.com/b/two", Version: "v0.3.1", Replace: &debug.Module{Path: "github.com/fork/two", Version: "v0.3.2"}},
+			{Path: "github.com/c/three", Version: "v1.0.0", Replace: &debug.Module{Path: "../three"}},
+		},
+	}
+
+	modules := ModulesFromBuildInfo(info)
+	if len(modules) != 4 {
+		t.Fatalf("ModulesFromBuildInfo() = %+v", modules)
+	}
+	if !modules[0].Main || modules[0].Path != "example.com/app" {
+		t.Errorf("main module = %+v", modules[0])
+	}
+	if m := modules[1]; m.Path != "github.com/a/one" || m.Version != "v1.2.0" || m.Replace != nil || m.Indirect {
+		t.Errorf("unexpected %+v", m)
+	}
+	if r := modules[2].Replace; r == nil || r.Path != "github.com/fork/two" || r.Version != "v0.3.2" {
+		t.Errorf("replacement = %+v", r)
+	}
+	if r := modules[3].Replace; r == nil || r.Path != "../three" || r.Version != "" {
+		t.Errorf("local replacement = %+v", r)
+	}
+}
@@ -0,0 +1,343 @@
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultCacheTTL is how long mutable metadata such as version lists and
+// repository statistics is trusted before it is fetched again
+const DefaultCacheTTL = 24 * time.Hour
+
+// cacheVersion is bumped when the entry format changes
+const cacheVersion = "v1"
+
+// DiskCache keeps fetched metadata between runs. Entries are JSON files
+// below Dir, named after their key, e.g.
+// v1/proxy/github.com/!burnt!sushi/toml/@v/list.json.
+type DiskCache struct {
+	Dir string
+	TTL time.Duration
+
+	// Lookups of this run, saved by saveRunStats
+	hits, misses, revalidated atomic.Int64
+	// offline serves every entry however old, as nothing can be fetched,
+	// and writes none, as what is read from disk instead may be incomplete
+	offline bool
+}
+
+// cacheEntry is a cached response with what is needed to revalidate it
+type cacheEntry struct {
+	Fetched time.Time `json:"fetched"`
+	// Data is the response body, or the JSON of a forge answer
+	Data []byte `json:"data,omitempty"`
+	// NotFound caches that the module, version or repository does not exist
+	NotFound bool `json:"not_found,omitempty"`
+	// ModCache marks data read from the module cache rather than a proxy
+	ModCache bool `json:"mod_cache,omitempty"`
+
+	// URL is where the data came from, and the validators it was served with
+	URL          string `json:"url,omitempty"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// NewDiskCache returns the cache for dir, or nil when dir is empty. A zero
+// ttl means DefaultCacheTTL.
+func NewDiskCache(dir string, ttl time.Duration) *DiskCache {
+	if dir == "" {
+		return nil
+	}
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	return &DiskCache{Dir: dir, TTL: ttl}
+}
+
+// DefaultCacheDir is the per-user cache directory of the tool
+func DefaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "go-dep-audit")
+}
+
+// get returns the entry for key and whether it can be used without asking
+// the server again. Immutable entries never expire. A nil cache has no
+// entries.
+func (c *DiskCache) get(key string, immutable bool) (*cacheEntry, bool) {
+	if c == nil {
+		return nil, false
+	}
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		c.misses.Add(1)
+		return nil, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		c.misses.Add(1)
+		return nil, false
+	}
+	fresh := c.offline || !c.expired(&entry, immutable, time.Now())
+	if fresh {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+	return &entry, fresh
+}
+
+// expired reports whether an entry needs to be fetched again at now
+func (c *DiskCache) expired(entry *cacheEntry, immutable bool, now time.Time) bool {
+	if immutable && !entry.NotFound {
+		return false
+	}
+	return now.Sub(entry.Fetched) >= c.TTL
+}
+
+// put stores an entry. The cache is an optimization, so failures to write
+// it are ignored.
+func (c *DiskCache) put(key string, entry *cacheEntry) {
+	if c == nil || c.offline {
+		return
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	writeFileAtomic(c.path(key), data)
+}
+
+// writeFileAtomic writes and renames, so concurrent runs never read half a
+// file
+func writeFileAtomic(name string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(name), tmpPrefix+"*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), name)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// tmpPrefix names files that are still being written
+const tmpPrefix = ".tmp-"
+
+// path maps a key to its file. Keys are slash-separated and made of
+// escaped module paths, so they are safe on case-insensitive file systems;
+// ".." elements are dropped.
+func (c *DiskCache) path(key string) string {
+	key = strings.TrimPrefix(path.Clean("/"+key), "/")
+	return filepath.Join(c.Dir, cacheVersion, filepath.FromSlash(key)+".json")
+}
+
+// immutableProxyFile reports whether a proxy file never changes once
+// published: the .info and .mod of a specific version
+func immutableProxyFile(file string) bool {
+	return strings.HasPrefix(file, "@v/") && (strings.HasSuffix(file, ".info") || strings.HasSuffix(file, ".mod"))
+}
+
+// keyImmutable is immutableProxyFile for a cache key
+func keyImmutable(key string) bool {
+	if !strings.HasPrefix(key, "proxy/") {
+		return false
+	}
+	i := strings.LastIndex(key, "/@v/")
+	return i >= 0 && immutableProxyFile(key[i+1:])
+}
+
+// CacheRunStats counts the cache lookups of the last audit run
+type CacheRunStats struct {
+	Finished time.Time `json:"finished"`
+	// Hits were served from the cache, Misses had to be fetched; Revalidated
+	// counts the misses the server confirmed unchanged
+	Hits        int64 `json:"hits"`
+	Misses      int64 `json:"misses"`
+	Revalidated int64 `json:"revalidated"`
+}
+
+// HitRatio is the share of lookups served from the cache
+func (s CacheRunStats) HitRatio() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// CacheStats describes the contents of a cache directory
+type CacheStats struct {
+	Dir string `json:"dir"`
+	// Size is the total size of the entries in bytes
+	Size    int64 `json:"size"`
+	Entries int   `json:"entries"`
+	// Kinds counts the entries by source: proxy, repo or vanity
+	Kinds    map[string]int `json:"kinds"`
+	Expired  int            `json:"expired"`
+	NotFound int            `json:"not_found"`
+	// LastRun is nil until an audit used the cache
+	LastRun *CacheRunStats `json:"last_run,omitempty"`
+}
+
+// statsFile holds the CacheRunStats of the last run, next to the entries
+func (c *DiskCache) statsFile() string {
+	return filepath.Join(c.Dir, "stats.json")
+}
+
+// saveRunStats records the lookups made so far. Stats are best effort like
+// the entries themselves.
+func (c *DiskCache) saveRunStats() {
+	if c == nil {
+		return
+	}
+	stats := CacheRunStats{
+		Finished:    time.Now(),
+		Hits:        c.hits.Load(),
+		Misses:      c.misses.Load(),
+		Revalidated: c.revalidated.Load(),
+	}
+	if stats.Hits+stats.Misses == 0 {
+		return
+	}
+	if data, err := json.Marshal(stats); err == nil {
+		writeFileAtomic(c.statsFile(), data)
+	}
+}
+
+// walk calls fn for every entry with its key, and for files left half
+// written, in no particular order
+func (c *DiskCache) walk(fn func(key, name string, info fs.FileInfo) error) error {
+	root := filepath.Join(c.Dir, cacheVersion)
+	err := filepath.WalkDir(root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if !strings.HasSuffix(name, ".json") && !strings.HasPrefix(d.Name(), tmpPrefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, name)
+		if err != nil {
+			return err
+		}
+		return fn(strings.TrimSuffix(filepath.ToSlash(rel), ".json"), name, info)
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// readEntry reads an entry file, nil when it is unreadable
+func readEntry(name string) *cacheEntry {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil
+	}
+	var entry cacheEntry
+	if json.Unmarshal(data, &entry) != nil {
+		return nil
+	}
+	return &entry
+}
+
+// Stats reads the size and contents of the cache and the stats of the last
+// run
+func (c *DiskCache) Stats() (*CacheStats, error) {
+	stats := &CacheStats{Dir: c.Dir, Kinds: make(map[string]int)}
+	now := time.Now()
+	err := c.walk(func(key, name string, info fs.FileInfo) error {
+		if strings.HasPrefix(path.Base(key), tmpPrefix) {
+			return nil
+		}
+		stats.Entries++
+		stats.Size += info.Size()
+		kind, _, _ := strings.Cut(key, "/")
+		stats.Kinds[kind]++
+		entry := readEntry(name)
+		if entry == nil || c.expired(entry, keyImmutable(key), now) {
+			stats.Expired++
+		}
+		if entry != nil && entry.NotFound {
+			stats.NotFound++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache %s: %w", c.Dir, err)
+	}
+
+	if data, err := os.ReadFile(c.statsFile()); err == nil {
+		var run CacheRunStats
+		if json.Unmarshal(data, &run) == nil {
+			stats.LastRun = &run
+		}
+	}
+	return stats, nil
+}
+
+// Prune removes expired entries, and with a non-zero before also every
+// entry fetched before it, immutable ones included. It returns how many
+// entries were removed.
+func (c *DiskCache) Prune(before time.Time) (int, error) {
+	now := time.Now()
+	removed := 0
+	err := c.walk(func(key, name string, info fs.FileInfo) error {
+		entry := readEntry(name)
+		switch {
+		case strings.HasPrefix(path.Base(key), tmpPrefix):
+			// Left behind by an interrupted run
+			if now.Sub(info.ModTime()) < time.Hour {
+				return nil
+			}
+		case entry == nil, c.expired(entry, keyImmutable(key), now):
+		case !before.IsZero() && entry.Fetched.Before(before):
+		default:
+			return nil
+		}
+		if err := os.Remove(name); err != nil {
+			return err
+		}
+		if !strings.HasPrefix(path.Base(key), tmpPrefix) {
+			removed++
+		}
+		return nil
+	})
+	if err != nil {
+		return removed, fmt.Errorf("failed to prune cache %s: %w", c.Dir, err)
+	}
+	return removed, nil
+}
+
+// Clear removes every entry and the run stats
+func (c *DiskCache) Clear() error {
+	for _, name := range []string{filepath.Join(c.Dir, cacheVersion), c.statsFile()} {
+		if err := os.RemoveAll(name); err != nil {
+			return fmt.Errorf("failed to clear cache %s: %w", c.Dir, err)
+		}
+	}
+	return nil
+}
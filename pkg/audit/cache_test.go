@@ -0,0 +1,171 @@
+package audit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiskCache(t *testing.T) {
+	c := NewDiskCache(t.TempDir(), time.Hour)
+	if _, ok := c.get("proxy/example.com/mod/@v/list", false); ok {
+		t.Fatal("empty cache returned an entry")
+	}
+
+	c.put("proxy/example.com/mod/@v/list", &cacheEntry{Fetched: time.Now(), Data: []byte("v1.0.0\n")})
+	c.put("proxy/example.com/mod/@v/v1.0.0.info", &cacheEntry{Fetched: time.Now().Add(-48 * time.Hour), Data: []byte("{}")})
+	c.put("proxy/example.com/mod/@v/v9.0.0.info", &cacheEntry{Fetched: time.Now().Add(-48 * time.Hour), NotFound: true})
+
+	tests := []struct {
+		key       string
+		immutable bool
+		fresh     bool
+	}{
+		{"proxy/example.com/mod/@v/list", false, true},
+		{"proxy/example.com/mod/@v/v1.0.0.info", true, true},
+		{"proxy/example.com/mod/@v/v9.0.0.info", true, false},
+	}
+	for _, tt := range tests {
+		entry, fresh := c.get(tt.key, tt.immutable)
+		if entry == nil || fresh != tt.fresh {
+			t.Errorf("get(%q) = %+v, %v, want fresh %v", tt.key, entry, fresh, tt.fresh)
+		}
+	}
+
+	// Keys cannot escape the cache directory
+	if p := c.path("../../etc/passwd"); !strings.HasPrefix(p, c.Dir) {
+		t.Errorf("path escaped the cache: %s", p)
+	}
+
+	var nilCache *DiskCache
+	nilCache.put("key", &cacheEntry{})
+	if _, ok := nilCache.get("key", true); ok {
+		t.Error("nil cache returned an entry")
+	}
+}
+
+func TestProxyGetCache(t *testing.T) {
+	var requests []string
+	down := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path+" "+r.Header.Get("If-None-Match"))
+		if down {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		switch r.URL.Path {
+		case "/example.com/mod/@v/list":
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			w.Write([]byte("v1.0.0\n"))
+		case "/example.com/mod/@v/v1.0.0.info":
+			w.Write([]byte(`{"Version":"v1.0.0","Time":"2024-01-01T00:00:00Z"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	t.Setenv("GOMODCACHE", t.TempDir())
+	dir := t.TempDir()
+	run := func(ttl time.Duration) {
+		t.Helper()
+		f := NewFetcher(AuditConfig{GoProxy: srv.URL, CacheDir: dir, CacheTTL: ttl})
+		if versions, err := f.fetchVersionList(context.Background(), "example.com/mod"); err != nil || len(versions) != 1 {
+			t.Fatalf("fetchVersionList() = %v, %v", versions, err)
+		}
+		if _, err := f.fetchProxyInfo(context.Background(), "example.com/mod", "v1.0.0"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.fetchVersionList(context.Background(), "example.com/missing"); err == nil {
+			t.Fatal("expected an error for a missing module")
+		}
+	}
+
+	run(time.Hour)
+	if len(requests) != 3 {
+		t.Fatalf("first run made %d requests: %v", len(requests), requests)
+	}
+
+	requests = nil
+	run(time.Hour)
+	if len(requests) != 0 {
+		t.Errorf("fresh cache still made requests: %v", requests)
+	}
+
+	// Expired: the list is revalidated, the .info is immutable
+	requests = nil
+	run(time.Nanosecond)
+	want := []string{`/example.com/mod/@v/list "v1"`, "/example.com/missing/@v/list "}
+	if strings.Join(requests, ",") != strings.Join(want, ",") {
+		t.Errorf("expired cache requests = %v, want %v", requests, want)
+	}
+
+	// An unreachable proxy falls back to the expired list
+	down = true
+	f := NewFetcher(AuditConfig{GoProxy: srv.URL, CacheDir: dir, CacheTTL: time.Nanosecond, MaxRetries: -1})
+	if versions, err := f.fetchVersionList(context.Background(), "example.com/mod"); err != nil || len(versions) != 1 {
+		t.Errorf("fetchVersionList() with the proxy down = %v, %v", versions, err)
+	}
+
+	entries, _ := filepath.Glob(filepath.Join(dir, cacheVersion, "proxy", "example.com", "mod", "@v", "*.json"))
+	if len(entries) != 2 {
+		t.Errorf("cache files = %v", entries)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, cacheVersion, "proxy", "example.com", "mod", "@v", "list.json"))
+	if !strings.Contains(string(data), `"etag"`) {
+		t.Errorf("list entry has no validator: %s", data)
+	}
+}
+
+func TestDiskCacheManagement(t *testing.T) {
+	c := NewDiskCache(t.TempDir(), time.Hour)
+	old := time.Now().Add(-48 * time.Hour)
+	c.put("proxy/example.com/mod/@v/list", &cacheEntry{Fetched: time.Now(), Data: []byte("v1.0.0\n")})
+	c.put("proxy/example.com/mod/@v/v1.0.0.info", &cacheEntry{Fetched: old, Data: []byte("{}")})
+	c.put("proxy/example.com/gone/@v/list", &cacheEntry{Fetched: old, NotFound: true})
+	c.put("repo/github.com/owner/repo", &cacheEntry{Fetched: old, Data: []byte("{}")})
+	os.WriteFile(filepath.Join(c.Dir, cacheVersion, "repo", "github.com", "owner", tmpPrefix+"123"), []byte("{"), 0o644)
+
+	c.get("proxy/example.com/mod/@v/list", false)
+	c.get("proxy/example.com/other/@v/list", false)
+	c.saveRunStats()
+
+	stats, err := c.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Entries != 4 || stats.Kinds["proxy"] != 3 || stats.Kinds["repo"] != 1 || stats.Expired != 2 || stats.NotFound != 1 || stats.Size == 0 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+	if stats.LastRun == nil || stats.LastRun.Hits != 1 || stats.LastRun.Misses != 1 || stats.LastRun.HitRatio() != 0.5 {
+		t.Errorf("LastRun = %+v", stats.LastRun)
+	}
+
+	// Expired entries go, the old immutable .info stays
+	if removed, err := c.Prune(time.Time{}); err != nil || removed != 2 {
+		t.Errorf("Prune() = %d, %v, want 2", removed, err)
+	}
+	if _, ok := c.get("proxy/example.com/mod/@v/v1.0.0.info", true); !ok {
+		t.Error("Prune() removed an immutable entry")
+	}
+	if removed, err := c.Prune(time.Now().Add(-time.Hour)); err != nil || removed != 1 {
+		t.Errorf("Prune(before) = %d, %v, want 1", removed, err)
+	}
+
+	if err := c.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	stats, err = c.Stats()
+	if err != nil || stats.Entries != 0 || stats.LastRun != nil {
+		t.Errorf("Stats() after Clear() = %+v, %v", stats, err)
+	}
+}
@@ -10,6 +10,29 @@ type AuditConfig struct {
 	CacheDir          string        `json:"cache_dir" yaml:"cache_dir"`
 	CacheTTL          time.Duration `json:"cache_ttl" yaml:"cache_ttl"`
 
+	// Offline never touches the network: metadata is read from the cache
+	// directory, file:// proxies in GOPROXY and the module cache's
+	// cache/download. Signals that cannot be found there are unknown.
+	Offline bool `json:"offline" yaml:"offline"`
+
+	// Vendor audits the modules in vendor/modules.txt using only the vendored
+	// sources, without network access
+	Vendor bool `json:"vendor" yaml:"vendor"`
+
+	// AnalyzeReachability lists the project's packages to tell modules that
+	// are linked into binaries from test-only and graph-only ones. It needs
+	// the go command.
+	AnalyzeReachability bool `json:"analyze_reachability" yaml:"analyze_reachability"`
+	// BuildOnly only audits modules linked into binaries; it implies
+	// AnalyzeReachability
+	BuildOnly bool `json:"build_only" yaml:"build_only"`
+	// Platforms are the GOOS/GOARCH/tags combinations that get shipped.
+	// When set, reachability is analyzed for each of them instead of the
+	// host platform, and modules record where they are linked. This only
+	// annotates the results; scores and categories are unchanged, and only
+	// BuildOnly drops modules that no shipped platform links in.
+	Platforms []BuildTarget `json:"platforms" yaml:"platforms"`
+
 	// Scoring weights and thresholds
 	Scoring ScoringConfig `json:"scoring" yaml:"scoring"`
 
@@ -22,6 +45,48 @@ type AuditConfig struct {
 	// API tokens
 	GitHubToken string `json:"github_token" yaml:"github_token"`
 	GitLabToken string `json:"gitlab_token" yaml:"gitlab_token"`
+
+	// GitHubBaseURL is the GitHub REST API root, https://api.github.com
+	// when empty
+	GitHubBaseURL string `json:"github_base_url" yaml:"github_base_url"`
+
+	// GoProxy, GoPrivate and GoNoProxy work like the go environment
+	// variables and default to them: module metadata is fetched from the
+	// GOPROXY list, and modules matching GONOPROXY (GOPRIVATE by default)
+	// are only read from the local module cache
+	GoProxy   string `json:"goproxy" yaml:"goproxy"`
+	GoPrivate string `json:"goprivate" yaml:"goprivate"`
+	GoNoProxy string `json:"gonoproxy" yaml:"gonoproxy"`
+
+	// AuthHeaders are request headers to send per host, e.g. an
+	// Authorization header for a private proxy or forge. They are only
+	// sent over HTTPS.
+	AuthHeaders map[string]map[string]string `json:"auth_headers" yaml:"auth_headers"`
+	// GoAuth works like GOAUTH and defaults to it, else to netrc: a
+	// semicolon-separated list of "netrc", "off" or helper commands
+	GoAuth string `json:"goauth" yaml:"goauth"`
+
+	// Forges registers self-hosted GitLab, Gitea/Forgejo, GitHub Enterprise
+	// or Bitbucket instances for repository metadata. An entry for a public
+	// forge host overrides its defaults.
+	Forges []ForgeConfig `json:"forges" yaml:"forges"`
+
+	// RateLimits caps the requests per second sent to a host, on top of
+	// the default limit for api.github.com; 0 lifts a limit
+	RateLimits map[string]float64 `json:"rate_limits" yaml:"rate_limits"`
+	// MaxRetries is how often failed requests are retried, DefaultMaxRetries
+	// when 0; negative disables retries
+	MaxRetries int `json:"max_retries" yaml:"max_retries"`
+
+	// MetadataSources is an ordered chain of sources to score modules on,
+	// merged field by field as a SourceChain. Include a *Fetcher from
+	// NewFetcher to keep the proxy and forge metadata; without it only the
+	// given sources are asked. Newer versions and licenses are looked up as
+	// usual.
+	MetadataSources []MetadataSource `json:"-" yaml:"-"`
+
+	// Verbose prints retries, rate limit waits and similar decisions
+	Verbose bool `json:"verbose" yaml:"verbose"`
 }
 
 // ScoringConfig defines weights and thresholds for health scoring
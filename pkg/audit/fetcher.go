@@ -3,28 +3,102 @@ package audit
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 )
 
-// Fetcher handles metadata retrieval
+// Fetcher handles metadata retrieval. A Fetcher can be shared between
+// audits; every proxy response is fetched once per Fetcher, and kept
+// between runs when the config has a CacheDir.
 type Fetcher struct {
 	client *http.Client
 	config AuditConfig
+	cache  *DiskCache
+
+	// proxies is the GOPROXY list, noProxy the GONOPROXY patterns
+	proxies []proxyEntry
+	noProxy string
+
+	// providers fetch repository metadata, by forge host
+	providers map[string]RepoProvider
+
+	mu    sync.Mutex
+	memo  map[string]*memoCall[*cacheEntry]
+	repos map[string]*memoCall[*RepoMetadata]
+	// vanity holds the repositories of vanity import paths
+	vanity map[string]*memoCall[string]
+}
+
+// memoCall is a request that is in flight or done
+type memoCall[T any] struct {
+	done chan struct{}
+	val  T
+	err  error
+}
+
+// memoize runs fn once per key; concurrent callers for the same key wait
+// for the first one and share its result
+func memoize[T any](ctx context.Context, mu *sync.Mutex, memo map[string]*memoCall[T], key string, fn func() (T, error)) (T, error) {
+	mu.Lock()
+	call, ok := memo[key]
+	if !ok {
+		call = &memoCall[T]{done: make(chan struct{})}
+		memo[key] = call
+	}
+	mu.Unlock()
+
+	if ok {
+		select {
+		case <-call.done:
+			return call.val, call.err
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		}
+	}
+	call.val, call.err = fn()
+	close(call.done)
+	return call.val, call.err
 }
 
 func NewFetcher(config AuditConfig) *Fetcher {
+	// Every attempt has its own timeout, waits between retries excluded
+	client := &http.Client{
+		Transport: newRetryTransport(config, newAuthTransport(config, http.DefaultTransport)),
+	}
+	proxies, noProxy := goProxySettings(config)
+	cache := NewDiskCache(config.CacheDir, config.CacheTTL)
+	if config.Offline {
+		client.Transport = offlineTransport{}
+		proxies = offlineProxies(proxies)
+		if cache != nil {
+			cache.offline = true
+		}
+	}
 	return &Fetcher{
-		client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
-		config: config,
+		client:    client,
+		config:    config,
+		cache:     cache,
+		proxies:   proxies,
+		noProxy:   noProxy,
+		providers: repoProviders(config, client),
+		memo:      make(map[string]*memoCall[*cacheEntry]),
+		repos:     make(map[string]*memoCall[*RepoMetadata]),
+		vanity:    make(map[string]*memoCall[string]),
 	}
 }
 
-// ProxyInfo represents data from proxy.golang.org/{module}/@v/{version}.info
+// ProxyInfo represents data from $GOPROXY/{module}/@v/{version}.info
 type ProxyInfo struct {
 	Version string    `json:"Version"`
 	Time    time.Time `json:"Time"`
@@ -42,61 +116,254 @@ func (f *Fetcher) FetchModuleMetadata(ctx context.Context, modulePath, version s
 		return nil, fmt.Errorf("failed to fetch proxy info: %w", err)
 	}
 	meta.LastCommitDate = proxyInfo.Time
+	meta.declare(f.Name(), SignalLastRelease)
 	
-	// 2. Fetch version list to count versions
-	versions, err := f.fetchVersionList(ctx, modulePath)
+	// 2. Fetch the release history for version count and cadence
+	releases, err := f.fetchReleaseHistory(ctx, modulePath)
 	if err == nil {
-		meta.VersionCount = len(versions)
-		// Calculate frequency based on versions? 
-		// For now just store count
+		meta.VersionCount = len(releases)
+		applyReleaseCadence(meta, releases, time.Now())
+		meta.declare(f.Name(), SignalReleaseHistory)
+	} else {
+		meta.Unknown = append(meta.Unknown, SignalReleaseHistory)
+	}
+
+	// 3. Deprecation notice of the module, kept in the latest go.mod
+	if mod, err := f.fetchLatestModFile(ctx, modulePath); err == nil {
+		meta.Deprecated = mod.Deprecated
 	}
 
-	// 3. Fetch Repository Metadata (if enabled and possible)
+	// 4. Fetch Repository Metadata (if enabled and possible)
 	if f.config.FetchRepoMetadata {
-		repoURL := getRepoURL(modulePath)
+		repoURL := f.resolveRepoURL(ctx, modulePath)
 		if repoURL != "" {
 			meta.RepositoryURL = repoURL
-			// TODO: Implement GitHub/GitLab API fetching here
-			// For now, we'll leave the repo stats as 0
+			// Without repo stats the module is still scored on proxy data
+			if repo, err := f.fetchRepoMetadata(ctx, repoURL); err == nil {
+				repo.apply(meta)
+				meta.declare(f.Name(), SignalRepository)
+			} else {
+				meta.Unknown = append(meta.Unknown, SignalRepository)
+			}
 		}
 	}
 
 	return meta, nil
 }
 
+// fetchProxyInfo returns the .info of a version, preferring the local
+// module cache, so modules the proxy cannot serve still get scored
 func (f *Fetcher) fetchProxyInfo(ctx context.Context, modulePath, version string) (*ProxyInfo, error) {
-	url := fmt.Sprintf("https://proxy.golang.org/%s/@v/%s.info", strings.ToLower(modulePath), version)
-	
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if dir := goModCacheDir(); dir != "" {
+		if p, err := modCachePath(dir, modulePath, version, "info"); err == nil {
+			if data, err := os.ReadFile(p); err == nil {
+				var info ProxyInfo
+				if err := json.Unmarshal(data, &info); err == nil {
+					return &info, nil
+				}
+			}
+		}
+	}
+	return f.fetchProxyInfoFile(ctx, modulePath, "@v/"+version+".info")
+}
+
+// fetchProxyInfoFile reads an .info-style JSON response, e.g. @latest
+func (f *Fetcher) fetchProxyInfoFile(ctx context.Context, modulePath, file string) (*ProxyInfo, error) {
+	body, err := f.proxyGet(ctx, modulePath, file)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := f.client.Do(req)
+	var info ProxyInfo
+	if err := json.Unmarshal(body, &info); err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// fetchVersionList returns the tagged versions of a module, in semver
+// order. Pseudo-versions are not listed by the proxy. The module cache only
+// lists the versions that were downloaded, which would make old versions
+// look current, so offline its lists are not used.
+func (f *Fetcher) fetchVersionList(ctx context.Context, modulePath string) ([]string, error) {
+	entry, err := f.proxyGetEntry(ctx, modulePath, "@v/list")
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	// Offline, the module cache stands in for every module, and a list of
+	// what happened to be downloaded would make them all look up to date.
+	// Online it only serves direct modules, which have nothing better.
+	if entry.ModCache && f.config.Offline {
+		return nil, fmt.Errorf("%s: the module cache does not list every version", modulePath)
+	}
+	return parseVersionList(entry.Data), nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("proxy returned status: %d", resp.StatusCode)
+// parseVersionList parses the @v/list response, one version per line
+func parseVersionList(body []byte) []string {
+	var versions []string
+	for _, line := range strings.Split(string(body), "\n") {
+		// Lines may carry extra fields after the version
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if _, ok := parseSemver(fields[0]); ok {
+			versions = append(versions, fields[0])
+		}
 	}
+	sort.Slice(versions, func(i, j int) bool {
+		return compareVersions(versions[i], versions[j]) < 0
+	})
+	return versions
+}
 
-	var info ProxyInfo
-	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+// fetchReleaseHistory returns every tagged version with its timestamp,
+// oldest first. Versions whose .info cannot be fetched are left out.
+func (f *Fetcher) fetchReleaseHistory(ctx context.Context, modulePath string) ([]ProxyInfo, error) {
+	versions, err := f.fetchVersionList(ctx, modulePath)
+	if err != nil {
 		return nil, err
 	}
 
-	return &info, nil
+	infos := make([]*ProxyInfo, len(versions))
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, 10) // Limit concurrency
+	for i, v := range versions {
+		wg.Add(1)
+		go func(i int, v string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			if info, err := f.fetchProxyInfo(ctx, modulePath, v); err == nil {
+				infos[i] = info
+			}
+		}(i, v)
+	}
+	wg.Wait()
+
+	var releases []ProxyInfo
+	for _, info := range infos {
+		if info != nil && !info.Time.IsZero() {
+			releases = append(releases, *info)
+		}
+	}
+	sort.SliceStable(releases, func(i, j int) bool {
+		return releases[i].Time.Before(releases[j].Time)
+	})
+	return releases, nil
 }
 
-func (f *Fetcher) fetchVersionList(ctx context.Context, modulePath string) ([]string, error) {
-	url := fmt.Sprintf("https://proxy.golang.org/%s/@v/list", strings.ToLower(modulePath))
-	
+// applyReleaseCadence derives the release cadence metrics from the release
+// history, sorted oldest first
+func applyReleaseCadence(meta *ModuleMetadata, releases []ProxyInfo, now time.Time) {
+	if len(releases) == 0 {
+		return
+	}
+	meta.FirstRelease = releases[0].Time
+
+	yearAgo := now.AddDate(-1, 0, 0)
+	majors := make(map[string]bool)
+	var intervals []float64
+	for i, r := range releases {
+		if r.Time.After(yearAgo) {
+			meta.ReleasesLastYear++
+		}
+		if sv, ok := parseSemver(r.Version); ok {
+			majors[sv.major] = true
+		}
+		if i > 0 {
+			intervals = append(intervals, r.Time.Sub(releases[i-1].Time).Hours()/24)
+		}
+	}
+	meta.MajorVersions = len(majors)
+
+	if len(intervals) > 0 {
+		sort.Float64s(intervals)
+		mid := len(intervals) / 2
+		median := intervals[mid]
+		if len(intervals)%2 == 0 {
+			median = (intervals[mid-1] + intervals[mid]) / 2
+		}
+		meta.MedianReleaseInterval = math.Round(median*10) / 10
+	}
+}
+
+// fetchModFile returns the go.mod of a module version, preferring the local
+// module cache over the proxy
+func (f *Fetcher) fetchModFile(ctx context.Context, modulePath, version string) ([]byte, error) {
+	if dir := goModCacheDir(); dir != "" {
+		if p, err := modCachePath(dir, modulePath, version, "mod"); err == nil {
+			if data, err := os.ReadFile(p); err == nil {
+				return data, nil
+			}
+		}
+	}
+	return f.proxyGet(ctx, modulePath, "@v/"+version+".mod")
+}
+
+// proxyGet fetches a file below the module's path from the GOPROXY list,
+// e.g. "@v/list" or "@v/v1.2.3.info"
+func (f *Fetcher) proxyGet(ctx context.Context, modulePath, file string) ([]byte, error) {
+	entry, err := f.proxyGetEntry(ctx, modulePath, file)
+	if err != nil {
+		return nil, err
+	}
+	return entry.Data, nil
+}
+
+// proxyGetEntry is proxyGet with where the data came from
+func (f *Fetcher) proxyGetEntry(ctx context.Context, modulePath, file string) (*cacheEntry, error) {
+	escaped, err := escapeModulePath(modulePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return memoize(ctx, &f.mu, f.memo, escaped+"/"+file, func() (*cacheEntry, error) {
+		key := "proxy/" + escaped + "/" + file
+		cached, fresh := f.cache.get(key, immutableProxyFile(file))
+		if fresh {
+			if cached.NotFound {
+				return nil, fmt.Errorf("%s/%s: %w", escaped, file, errNotFound)
+			}
+			return cached, nil
+		}
+
+		entry, err := f.proxyFetch(ctx, f.proxiesFor(modulePath), escaped, file, cached)
+		switch {
+		case err == nil:
+			f.cache.put(key, entry)
+			return entry, nil
+		case errors.Is(err, errNotFound):
+			f.cache.put(key, &cacheEntry{Fetched: time.Now(), NotFound: true})
+		case cached != nil && !cached.NotFound:
+			// An expired answer beats none when the proxy is unreachable
+			return cached, nil
+		}
+		return nil, err
+	})
+}
+
+// httpGet fetches url and returns the body of a 200 response. An expired
+// cache entry for the same URL makes the request conditional; a 304 then
+// renews it.
+func (f *Fetcher) httpGet(ctx context.Context, url string, cached *cacheEntry) (*cacheEntry, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
+	// Entries never hold credentials from the URL
+	source := redactURL(url)
+	revalidate := cached != nil && !cached.NotFound && cached.URL == source
+	if revalidate {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
 
 	resp, err := f.client.Do(req)
 	if err != nil {
@@ -104,27 +371,100 @@ func (f *Fetcher) fetchVersionList(ctx context.Context, modulePath string) ([]st
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		if revalidate {
+			f.cache.revalidated.Add(1)
+			renewed := *cached
+			renewed.Fetched = time.Now()
+			return &renewed, nil
+		}
+		return nil, fmt.Errorf("proxy returned status: %d", resp.StatusCode)
+	case http.StatusNotFound, http.StatusGone:
+		return nil, fmt.Errorf("proxy returned status: %d: %w", resp.StatusCode, errNotFound)
+	default:
 		return nil, fmt.Errorf("proxy returned status: %d", resp.StatusCode)
 	}
 
-	var versions []string
-	// The list endpoint returns a text list of versions, one per line
-	// We can read it into a buffer
-	// For simplicity, let's just count lines if we only need count, 
-	// but we might need parsing later.
-	// Implementation omitted for brevity, returning empty for now
-	return versions, nil
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &cacheEntry{
+		Fetched:      time.Now(),
+		Data:         data,
+		URL:          source,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}, nil
 }
 
-// Helper to guess repo URL from module path
-func getRepoURL(modulePath string) string {
-	if strings.HasPrefix(modulePath, "github.com/") {
-		parts := strings.Split(modulePath, "/")
-		if len(parts) >= 3 {
-			return fmt.Sprintf("https://github.com/%s/%s", parts[1], parts[2])
+// escapeModulePath applies the module proxy case encoding: every upper-case
+// letter becomes '!' followed by its lower-case form, so paths stay unique
+// on case-insensitive file systems
+func escapeModulePath(path string) (string, error) {
+	var b strings.Builder
+	for _, r := range path {
+		switch {
+		case r == '!' || r >= utf8.RuneSelf:
+			return "", fmt.Errorf("invalid module path %q", path)
+		case 'A' <= r && r <= 'Z':
+			b.WriteByte('!')
+			b.WriteRune(r + 'a' - 'A')
+		default:
+			b.WriteRune(r)
 		}
 	}
-	// Add gitlab, bitbucket etc.
-	return ""
+	return b.String(), nil
+}
+
+// goModCacheDir returns the module cache root the go command would use
+func goModCacheDir() string {
+	if dir := goEnv("GOMODCACHE"); dir != "" {
+		return dir
+	}
+	gopath := goEnv("GOPATH")
+	if gopath == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		gopath = filepath.Join(home, "go")
+	}
+	return filepath.Join(filepath.SplitList(gopath)[0], "pkg", "mod")
+}
+
+// modCachePath returns the path of a module version's .info, .mod or .zip
+// file in the download cache below modCache
+func modCachePath(modCache, modulePath, version, ext string) (string, error) {
+	return proxyDirPath(filepath.Join(modCache, "cache", "download"), modulePath, version, ext)
+}
+
+// proxyDirPath returns the path of a module version's file in a directory
+// with the module proxy layout
+func proxyDirPath(dir, modulePath, version, ext string) (string, error) {
+	escPath, err := escapeModulePath(modulePath)
+	if err != nil {
+		return "", err
+	}
+	escVersion, err := escapeModulePath(version)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, filepath.FromSlash(escPath), "@v", escVersion+"."+ext), nil
+}
+
+// modCacheSourceDir returns the directory a module version is extracted to
+// in the module cache, e.g. $GOMODCACHE/github.com/!burnt!sushi/toml@v1.3.2
+func modCacheSourceDir(modCache, modulePath, version string) (string, error) {
+	escPath, err := escapeModulePath(modulePath)
+	if err != nil {
+		return "", err
+	}
+	escVersion, err := escapeModulePath(version)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(modCache, filepath.FromSlash(escPath)+"@"+escVersion), nil
 }
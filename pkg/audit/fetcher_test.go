@@ -0,0 +1,48 @@
+package audit
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseVersionList(t *testing.T) {
+	body := "v1.10.0\nv1.2.0\n\nv2.0.0+incompatible\nnot-a-version\nv1.2.0-rc.1\n"
+	want := []string{"v1.2.0-rc.1", "v1.2.0", "v1.10.0", "v2.0.0+incompatible"}
+	if got := parseVersionList([]byte(body)); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseVersionList() = %v, want %v", got, want)
+	}
+}
+
+func TestApplyReleaseCadence(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	releases := []ProxyInfo{
+		{Version: "v0.9.0", Time: now.AddDate(-3, 0, 0)},
+		{Version: "v1.0.0", Time: now.AddDate(-3, 0, 10)},
+		{Version: "v1.1.0", Time: now.AddDate(-3, 0, 40)},
+		{Version: "v2.0.0+incompatible", Time: now.AddDate(0, -2, 0)},
+	}
+
+	var meta ModuleMetadata
+	applyReleaseCadence(&meta, releases, now)
+
+	if !meta.FirstRelease.Equal(releases[0].Time) {
+		t.Errorf("FirstRelease = %v", meta.FirstRelease)
+	}
+	if meta.ReleasesLastYear != 1 {
+		t.Errorf("ReleasesLastYear = %d, want 1", meta.ReleasesLastYear)
+	}
+	if meta.MajorVersions != 3 {
+		t.Errorf("MajorVersions = %d, want 3", meta.MajorVersions)
+	}
+	// Intervals are 10, 30 and ~1000 days
+	if meta.MedianReleaseInterval != 30 {
+		t.Errorf("MedianReleaseInterval = %v, want 30", meta.MedianReleaseInterval)
+	}
+
+	var empty ModuleMetadata
+	applyReleaseCadence(&empty, nil, now)
+	if !reflect.DeepEqual(empty, ModuleMetadata{}) {
+		t.Errorf("no releases changed metadata: %+v", empty)
+	}
+}
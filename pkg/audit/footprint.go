@@ -1,49 +1,74 @@
 package audit
 
+import "math"
+
 // FootprintMetrics holds the result of footprint analysis
 type FootprintMetrics struct {
 	TransitiveDeps   int
 	MaxDepth         int
-	DependencyWeight float64
+	ExclusiveDeps    int     // deps that exist only because of this module
+	DependencyWeight float64 // share of the project graph this module is responsible for
 }
 
-// CalculateFootprint analyzes dependency graph complexity
-func CalculateFootprint(module Module, allModules []Module) FootprintMetrics {
-	// This requires building a graph from allModules to traverse
-	// For MVP, if we don't have the full graph structure (parent->child links),
-	// we can only estimate based on global stats or if 'go list -json' provided the tree.
-	// 'go list -json all' gives a flat list, but each module has a 'Dir' and we can't easily see who depends on whom
-	// without 'go mod graph'.
-	
-	// However, if we are auditing a specific module *within* the graph, we want to know *its* dependencies.
-	// That's hard from a flat list without the graph.
-	
-	// For the "Project" footprint, we can just count total modules.
-	// For a "Module" footprint (how heavy is this dependency?), we need the graph.
-	
-	// Let's assume for MVP we just return 0 for per-module footprint unless we parse 'go mod graph'.
-	// Parsing 'go mod graph' output is easy if we run it.
-	
-	return FootprintMetrics{
-		TransitiveDeps:   0,
-		MaxDepth:         0,
-		DependencyWeight: 0,
+// CalculateFootprint analyzes dependency graph complexity for one module.
+// Without a graph (e.g. when only go.mod could be parsed) nothing is known
+// and the metrics stay zero.
+func CalculateFootprint(module Module, graph *DependencyGraph) FootprintMetrics {
+	if graph == nil {
+		return FootprintMetrics{}
+	}
+
+	exclusive := len(graph.ExclusiveDependencies(module.Path))
+	metrics := FootprintMetrics{
+		TransitiveDeps: len(graph.Dependencies(module.Path)),
+		MaxDepth:       graph.MaxDepth(module.Path),
+		ExclusiveDeps:  exclusive,
 	}
+
+	// The module itself plus everything it alone drags in, relative to all
+	// non-root modules in the graph
+	total := len(graph.Modules()) - len(graph.Roots)
+	if total > 0 {
+		metrics.DependencyWeight = float64(exclusive+1) / float64(total)
+	}
+	return metrics
 }
 
-// CalculateProjectFootprint calculates the total footprint of the project
-func CalculateProjectFootprint(modules []Module) FootprintMetrics {
-	total := len(modules)
+// CalculateFootprintRisk maps footprint metrics to a 0-100 risk value. The
+// risk grows with the number of modules that exist only because of this one:
+// 10 exclusive deps = ~40, 20 = ~63, 50 = ~92.
+func CalculateFootprintRisk(metrics FootprintMetrics) float64 {
+	risk := 100 * (1 - math.Exp(-float64(metrics.ExclusiveDeps)/20.0))
+	return math.Round(risk*10) / 10
+}
+
+// CalculateProjectFootprint calculates the total footprint of the project.
+// MaxDepth is only known when a graph is available.
+func CalculateProjectFootprint(modules []Module, graph *DependencyGraph) FootprintMetrics {
+	total := 0
 	direct := 0
 	for _, m := range modules {
+		if m.Main {
+			continue
+		}
+		total++
 		if !m.Indirect {
 			direct++
 		}
 	}
-	
+
+	maxDepth := 0
+	if graph != nil {
+		for _, root := range graph.Roots {
+			if d := graph.MaxDepth(root); d > maxDepth {
+				maxDepth = d
+			}
+		}
+	}
+
 	return FootprintMetrics{
 		TransitiveDeps:   total - direct,
-		MaxDepth:         0, // Unknown without graph
+		MaxDepth:         maxDepth,
 		DependencyWeight: float64(total),
 	}
 }
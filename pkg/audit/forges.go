@@ -0,0 +1,301 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// recentCommitsSince is the start of the commit frequency window
+func recentCommitsSince() time.Time {
+	return time.Now().AddDate(0, 0, -commitWindowDays).UTC()
+}
+
+// headerCount reads a total count header such as X-Total
+func headerCount(resp *http.Response, name string) (int, bool) {
+	n, err := strconv.Atoi(resp.Header.Get(name))
+	return n, err == nil
+}
+
+// GitLabClient reads repository metadata from the GitLab REST API (v4)
+type GitLabClient struct {
+	// BaseURL is the instance root, https://gitlab.com unless set
+	BaseURL string
+	// Token is sent as a PRIVATE-TOKEN when set
+	Token string
+
+	client *http.Client
+}
+
+// NewGitLabClient returns a client for the GitLab instance at baseURL (""
+// for gitlab.com). A nil httpClient uses http.DefaultClient.
+func NewGitLabClient(baseURL, token string, httpClient *http.Client) *GitLabClient {
+	if baseURL == "" {
+		baseURL = "https://gitlab.com"
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &GitLabClient{BaseURL: strings.TrimSuffix(baseURL, "/"), Token: token, client: httpClient}
+}
+
+// FetchRepo returns the statistics of a project; repoPath may include
+// subgroups
+func (c *GitLabClient) FetchRepo(ctx context.Context, repoPath string) (*RepoMetadata, error) {
+	api := c.BaseURL + "/api/v4/projects/" + url.PathEscape(repoPath)
+
+	var project struct {
+		WebURL          string    `json:"web_url"`
+		StarCount       int       `json:"star_count"`
+		ForksCount      int       `json:"forks_count"`
+		OpenIssuesCount int       `json:"open_issues_count"`
+		LastActivityAt  time.Time `json:"last_activity_at"`
+		Archived        bool      `json:"archived"`
+	}
+	if _, err := c.get(ctx, api, &project); err != nil {
+		return nil, err
+	}
+
+	meta := &RepoMetadata{
+		URL:        project.WebURL,
+		Stars:      project.StarCount,
+		Forks:      project.ForksCount,
+		OpenIssues: project.OpenIssuesCount,
+		LastPush:   project.LastActivityAt,
+		Archived:   project.Archived,
+	}
+	// A count that fails is unknown, the project itself is still known
+	var err error
+	if meta.Contributors, err = c.count(ctx, api+"/repository/contributors?per_page=1"); err != nil {
+		meta.Unknown = append(meta.Unknown, SignalContributors)
+	}
+	since := url.QueryEscape(recentCommitsSince().Format(time.RFC3339))
+	if meta.RecentCommits, err = c.count(ctx, api+"/repository/commits?per_page=1&since="+since); err != nil {
+		meta.Unknown = append(meta.Unknown, SignalCommits)
+	}
+	return meta, nil
+}
+
+// count reads the total of a paginated list from its X-Total header.
+// Repositories without commits have no contributors or commits and answer
+// with a 404.
+func (c *GitLabClient) count(ctx context.Context, u string) (int, error) {
+	var items []struct{}
+	resp, err := c.get(ctx, u, &items, http.StatusNotFound)
+	if err != nil {
+		return 0, err
+	}
+	if n, ok := headerCount(resp, "X-Total"); ok {
+		return n, nil
+	}
+	// Very large lists only report the page count
+	if n, ok := headerCount(resp, "X-Total-Pages"); ok {
+		return n, nil
+	}
+	return len(items), nil
+}
+
+// get decodes a GitLab API response; statuses in empty leave v unset
+func (c *GitLabClient) get(ctx context.Context, u string, v interface{}, empty ...int) (*http.Response, error) {
+	header := http.Header{}
+	if c.Token != "" {
+		header.Set("PRIVATE-TOKEN", c.Token)
+	}
+	return getJSON(ctx, c.client, u, header, v, empty...)
+}
+
+// GiteaClient reads repository metadata from the Gitea or Forgejo API
+type GiteaClient struct {
+	// BaseURL is the instance root, https://codeberg.org unless set
+	BaseURL string
+	Token   string
+
+	client *http.Client
+}
+
+// NewGiteaClient returns a client for the Gitea or Forgejo instance at
+// baseURL ("" for codeberg.org). A nil httpClient uses http.DefaultClient.
+func NewGiteaClient(baseURL, token string, httpClient *http.Client) *GiteaClient {
+	if baseURL == "" {
+		baseURL = "https://codeberg.org"
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &GiteaClient{BaseURL: strings.TrimSuffix(baseURL, "/"), Token: token, client: httpClient}
+}
+
+// FetchRepo returns the statistics of an owner/repo repository. Gitea has
+// no contributors endpoint, so Contributors is unknown.
+func (c *GiteaClient) FetchRepo(ctx context.Context, repoPath string) (*RepoMetadata, error) {
+	owner, name, err := splitRepoPath(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	api := c.BaseURL + "/api/v1/repos/" + url.PathEscape(owner) + "/" + url.PathEscape(name)
+
+	var repo struct {
+		HTMLURL         string    `json:"html_url"`
+		StarsCount      int       `json:"stars_count"`
+		ForksCount      int       `json:"forks_count"`
+		OpenIssuesCount int       `json:"open_issues_count"`
+		UpdatedAt       time.Time `json:"updated_at"`
+		Archived        bool      `json:"archived"`
+	}
+	if _, err := c.get(ctx, api, &repo); err != nil {
+		return nil, err
+	}
+
+	meta := &RepoMetadata{
+		URL:        repo.HTMLURL,
+		Stars:      repo.StarsCount,
+		Forks:      repo.ForksCount,
+		OpenIssues: repo.OpenIssuesCount,
+		LastPush:   repo.UpdatedAt,
+		Archived:   repo.Archived,
+		Unknown:    []string{SignalContributors},
+	}
+
+	var commits []json.RawMessage
+	query := url.Values{
+		"since":        {recentCommitsSince().Format(time.RFC3339)},
+		"limit":        {"1"},
+		"stat":         {"false"},
+		"verification": {"false"},
+		"files":        {"false"},
+	}
+	resp, err := c.get(ctx, api+"/commits?"+query.Encode(), &commits)
+	if err != nil {
+		meta.Unknown = append(meta.Unknown, SignalCommits)
+		return meta, nil
+	}
+	if n, ok := headerCount(resp, "X-Total-Count"); ok {
+		meta.RecentCommits = n
+	} else if len(commits) > 0 {
+		// Without the total, one commit on a page says nothing
+		meta.Unknown = append(meta.Unknown, SignalCommits)
+	}
+	return meta, nil
+}
+
+func (c *GiteaClient) get(ctx context.Context, u string, v interface{}) (*http.Response, error) {
+	header := http.Header{}
+	if c.Token != "" {
+		header.Set("Authorization", "token "+c.Token)
+	}
+	// Empty repositories answer 409 for their commits
+	return getJSON(ctx, c.client, u, header, v, http.StatusConflict)
+}
+
+// BitbucketClient reads repository metadata from the Bitbucket Cloud API
+type BitbucketClient struct {
+	// BaseURL is the API root, https://api.bitbucket.org/2.0 unless set
+	BaseURL string
+	// Token is sent as a bearer token (repository or workspace access
+	// token) when set
+	Token string
+
+	client *http.Client
+}
+
+// NewBitbucketClient returns a client for the API at baseURL ("" for
+// Bitbucket Cloud). A nil httpClient uses http.DefaultClient.
+func NewBitbucketClient(baseURL, token string, httpClient *http.Client) *BitbucketClient {
+	if baseURL == "" {
+		baseURL = "https://api.bitbucket.org/2.0"
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &BitbucketClient{BaseURL: strings.TrimSuffix(baseURL, "/"), Token: token, client: httpClient}
+}
+
+// bitbucketMaxCommitPages bounds how far back the commit history is read
+const bitbucketMaxCommitPages = 10
+
+// FetchRepo returns the statistics of a workspace/repo repository.
+// Bitbucket has no stars, contributors or archived state, so Stars and
+// Contributors are unknown. Its commit list cannot be filtered by date, so
+// recent commits are paged through until the window ends.
+func (c *BitbucketClient) FetchRepo(ctx context.Context, repoPath string) (*RepoMetadata, error) {
+	workspace, name, err := splitRepoPath(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	api := c.BaseURL + "/repositories/" + url.PathEscape(workspace) + "/" + url.PathEscape(name)
+
+	var repo struct {
+		Links struct {
+			HTML struct {
+				Href string `json:"href"`
+			} `json:"html"`
+		} `json:"links"`
+		UpdatedOn time.Time `json:"updated_on"`
+		HasIssues bool      `json:"has_issues"`
+	}
+	if err := c.get(ctx, api, &repo); err != nil {
+		return nil, err
+	}
+
+	meta := &RepoMetadata{
+		URL:      repo.Links.HTML.Href,
+		LastPush: repo.UpdatedOn,
+		Unknown:  []string{SignalStars, SignalContributors},
+	}
+	if meta.Forks, err = c.size(ctx, api+"/forks?pagelen=1"); err != nil {
+		return nil, err
+	}
+	if repo.HasIssues {
+		q := url.QueryEscape(`state="new" OR state="open"`)
+		if meta.OpenIssues, err = c.size(ctx, api+"/issues?pagelen=1&q="+q); err != nil {
+			return nil, err
+		}
+	}
+
+	since := recentCommitsSince()
+	next := api + "/commits?pagelen=100"
+	for page := 0; next != "" && page < bitbucketMaxCommitPages; page++ {
+		var commits struct {
+			Values []struct {
+				Date time.Time `json:"date"`
+			} `json:"values"`
+			Next string `json:"next"`
+		}
+		if err := c.get(ctx, next, &commits); err != nil {
+			return nil, err
+		}
+		next = commits.Next
+		for _, commit := range commits.Values {
+			if commit.Date.Before(since) {
+				next = ""
+				break
+			}
+			meta.RecentCommits++
+		}
+	}
+	return meta, nil
+}
+
+// size reads the total of a paginated list
+func (c *BitbucketClient) size(ctx context.Context, u string) (int, error) {
+	var page struct {
+		Size int `json:"size"`
+	}
+	if err := c.get(ctx, u, &page); err != nil {
+		return 0, err
+	}
+	return page.Size, nil
+}
+
+func (c *BitbucketClient) get(ctx context.Context, u string, v interface{}) error {
+	header := http.Header{}
+	if c.Token != "" {
+		header.Set("Authorization", "Bearer "+c.Token)
+	}
+	_, err := getJSON(ctx, c.client, u, header, v)
+	return err
+}
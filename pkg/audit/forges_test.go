@@ -0,0 +1,245 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGitLabClientFetchRepo(t *testing.T) {
+	active := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/projects/", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("PRIVATE-TOKEN"); got != "secret" {
+			t.Errorf("PRIVATE-TOKEN = %q", got)
+		}
+		switch r.URL.EscapedPath() {
+		case "/api/v4/projects/group%2Fsub%2Fwidget":
+			fmt.Fprintf(w, `{"web_url":"https://gitlab.example.com/group/sub/widget","star_count":30,"forks_count":4,"open_issues_count":2,"last_activity_at":%q,"archived":true}`, active.Format(time.RFC3339))
+		case "/api/v4/projects/group%2Fsub%2Fwidget/repository/contributors":
+			w.Header().Set("X-Total", "9")
+			fmt.Fprint(w, `[{"name":"a"}]`)
+		case "/api/v4/projects/group%2Fsub%2Fwidget/repository/commits":
+			if r.URL.Query().Get("since") == "" {
+				t.Error("commits requested without since")
+			}
+			// No X-Total, counted from the page
+			fmt.Fprint(w, `[{"id":"1"},{"id":"2"}]`)
+		case "/api/v4/projects/group%2Fbig":
+			fmt.Fprint(w, `{"web_url":"https://gitlab.example.com/group/big","star_count":500,"archived":true}`)
+		case "/api/v4/projects/group%2Fbig/repository/contributors":
+			http.Error(w, "timeout", http.StatusInternalServerError)
+		case "/api/v4/projects/group%2Fbig/repository/commits":
+			fmt.Fprint(w, `[]`)
+		case "/api/v4/projects/group%2Fempty":
+			// Its contributors and commits are not found
+			fmt.Fprint(w, `{"web_url":"https://gitlab.example.com/group/empty"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := NewGitLabClient(srv.URL, "secret", srv.Client())
+	repo, err := client.FetchRepo(context.Background(), "group/sub/widget")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := RepoMetadata{
+		URL:           "https://gitlab.example.com/group/sub/widget",
+		Stars:         30,
+		Forks:         4,
+		OpenIssues:    2,
+		Contributors:  9,
+		RecentCommits: 2,
+		LastPush:      active,
+		Archived:      true,
+	}
+	if !reflect.DeepEqual(*repo, want) {
+		t.Errorf("FetchRepo() = %+v, want %+v", *repo, want)
+	}
+
+	empty, err := client.FetchRepo(context.Background(), "group/empty")
+	if err != nil || empty.Contributors != 0 || empty.RecentCommits != 0 {
+		t.Errorf("FetchRepo(empty) = %+v, %v", empty, err)
+	}
+	big, err := client.FetchRepo(context.Background(), "group/big")
+	if err != nil || !big.Archived || big.Stars != 500 || !reflect.DeepEqual(big.Unknown, []string{SignalContributors}) {
+		t.Errorf("FetchRepo(big) = %+v, %v, want the contributors unknown", big, err)
+	}
+	// A missing or private project must not be scored as an empty one
+	if repo, err := client.FetchRepo(context.Background(), "group/missing"); err == nil {
+		t.Errorf("FetchRepo(missing) = %+v, want an error", repo)
+	}
+}
+
+func TestGiteaClientFetchRepo(t *testing.T) {
+	updated := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/repos/acme/widget", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "token secret" {
+			t.Errorf("Authorization = %q", got)
+		}
+		fmt.Fprintf(w, `{"html_url":"https://codeberg.org/acme/widget","stars_count":15,"forks_count":3,"open_issues_count":1,"updated_at":%q}`, updated.Format(time.RFC3339))
+	})
+	mux.HandleFunc("/api/v1/repos/acme/widget/commits", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Total-Count", "12")
+		fmt.Fprint(w, `[{"commit":{"author":{"email":"a@example.com"}}}]`)
+	})
+	mux.HandleFunc("/api/v1/repos/acme/empty", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"html_url":"https://codeberg.org/acme/empty"}`)
+	})
+	mux.HandleFunc("/api/v1/repos/acme/empty/commits", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := NewGiteaClient(srv.URL, "secret", srv.Client())
+	repo, err := client.FetchRepo(context.Background(), "acme/widget")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := RepoMetadata{
+		URL:           "https://codeberg.org/acme/widget",
+		Stars:         15,
+		Forks:         3,
+		OpenIssues:    1,
+		RecentCommits: 12,
+		LastPush:      updated,
+		// Recent commit authors are no measure of all-time contributors
+		Unknown: []string{SignalContributors},
+	}
+	if !reflect.DeepEqual(*repo, want) {
+		t.Errorf("FetchRepo() = %+v, want %+v", *repo, want)
+	}
+
+	empty, err := client.FetchRepo(context.Background(), "acme/empty")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if empty.Contributors != 0 || empty.RecentCommits != 0 {
+		t.Errorf("empty repository = %+v", *empty)
+	}
+}
+
+func TestBitbucketClientFetchRepo(t *testing.T) {
+	now := time.Now().UTC()
+	var srv *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repositories/acme/widget", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q", got)
+		}
+		fmt.Fprint(w, `{"links":{"html":{"href":"https://bitbucket.org/acme/widget"}},"updated_on":"2024-02-01T00:00:00Z","has_issues":true}`)
+	})
+	mux.HandleFunc("/repositories/acme/widget/forks", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"size":5,"values":[]}`)
+	})
+	mux.HandleFunc("/repositories/acme/widget/issues", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("q") == "" {
+			t.Error("issues requested without a state filter")
+		}
+		fmt.Fprint(w, `{"size":3,"values":[]}`)
+	})
+	mux.HandleFunc("/repositories/acme/widget/commits", func(w http.ResponseWriter, r *http.Request) {
+		commit := func(days int, author string) string {
+			return fmt.Sprintf(`{"date":%q,"author":{"raw":%q}}`, now.AddDate(0, 0, -days).Format(time.RFC3339), author)
+		}
+		if r.URL.Query().Get("page") == "" {
+			fmt.Fprintf(w, `{"values":[%s,%s],"next":%q}`, commit(1, "A <a@example.com>"), commit(10, "B <b@example.com>"), srv.URL+"/repositories/acme/widget/commits?pagelen=100&page=2")
+			return
+		}
+		// The window ends on this page, the next one must not be fetched
+		fmt.Fprintf(w, `{"values":[%s,%s],"next":%q}`, commit(20, "A <a@example.com>"), commit(200, "C <c@example.com>"), srv.URL+"/repositories/acme/widget/commits?pagelen=100&page=3")
+	})
+	srv = httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := NewBitbucketClient(srv.URL, "secret", srv.Client())
+	repo, err := client.FetchRepo(context.Background(), "acme/widget")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := RepoMetadata{
+		URL:           "https://bitbucket.org/acme/widget",
+		Forks:         5,
+		OpenIssues:    3,
+		RecentCommits: 3,
+		LastPush:      time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		// Watchers are not stars, nor recent authors contributors
+		Unknown: []string{SignalStars, SignalContributors},
+	}
+	if !reflect.DeepEqual(*repo, want) {
+		t.Errorf("FetchRepo() = %+v, want %+v", *repo, want)
+	}
+}
+
+func TestRepoURL(t *testing.T) {
+	f := NewFetcher(AuditConfig{Forges: []ForgeConfig{{Host: "git.example.com", Type: ForgeGitLab}}})
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"github.com/spf13/cobra", "https://github.com/spf13/cobra"},
+		{"github.com/go-chi/chi/v5", "https://github.com/go-chi/chi"},
+		{"gitlab.com/group/sub/widget.git/v2", "https://gitlab.com/group/sub/widget"},
+		{"codeberg.org/acme/widget", "https://codeberg.org/acme/widget"},
+		{"bitbucket.org/acme/widget/pkg", "https://bitbucket.org/acme/widget"},
+		{"git.example.com/team/lib", "https://git.example.com/team/lib"},
+		{"github.com/spf13", ""},
+		{"golang.org/x/mod", ""},
+	}
+	for _, tt := range tests {
+		if got := f.repoURL(tt.path); got != tt.want {
+			t.Errorf("repoURL(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestNewRepoProvider(t *testing.T) {
+	p, err := newRepoProvider(ForgeConfig{Host: "ghe.example.com", Type: ForgeGitHub}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gh := p.(*GitHubClient); gh.BaseURL != "https://ghe.example.com/api/v3" {
+		t.Errorf("GitHub Enterprise BaseURL = %q", gh.BaseURL)
+	}
+
+	p, err = newRepoProvider(ForgeConfig{Host: "git.example.com", Type: "forgejo"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gt := p.(*GiteaClient); gt.BaseURL != "https://git.example.com" {
+		t.Errorf("Forgejo BaseURL = %q", gt.BaseURL)
+	}
+
+	if _, err := newRepoProvider(ForgeConfig{Host: "svn.example.com", Type: "svn"}, nil); err == nil {
+		t.Error("expected an error for an unknown forge type")
+	}
+}
+
+func TestParseForge(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    ForgeConfig
+		wantErr bool
+	}{
+		{"git.example.com=gitlab", ForgeConfig{Host: "git.example.com", Type: ForgeGitLab}, false},
+		{"ghe.example.com=github,https://ghe.example.com/api/v3", ForgeConfig{Host: "ghe.example.com", Type: ForgeGitHub, BaseURL: "https://ghe.example.com/api/v3"}, false},
+		{"git.example.com", ForgeConfig{}, true},
+		{"=gitea", ForgeConfig{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseForge(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseForge(%q) = %+v, %v", tt.in, got, err)
+		}
+	}
+}
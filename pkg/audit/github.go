@@ -0,0 +1,138 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultGitHubBaseURL = "https://api.github.com"
+
+// GitHubClient reads repository metadata from the GitHub REST API
+type GitHubClient struct {
+	// BaseURL is the API root, https://api.github.com unless set
+	BaseURL string
+	// Token is sent as a bearer token when set; anonymous requests are
+	// limited to 60 per hour
+	Token string
+
+	client *http.Client
+}
+
+// NewGitHubClient returns a client for the API at baseURL ("" for
+// api.github.com). A nil httpClient uses http.DefaultClient.
+func NewGitHubClient(baseURL, token string, httpClient *http.Client) *GitHubClient {
+	if baseURL == "" {
+		baseURL = defaultGitHubBaseURL
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &GitHubClient{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		Token:   token,
+		client:  httpClient,
+	}
+}
+
+// FetchRepo returns the statistics of an owner/repo repository.
+// Contributors and recent commits are counted from the pagination of
+// one-item pages, so the whole lists never have to be downloaded. GitHub
+// refuses to count the contributors of very large repositories; a count
+// that fails is reported unknown.
+func (c *GitHubClient) FetchRepo(ctx context.Context, repoPath string) (*RepoMetadata, error) {
+	owner, repo, err := splitRepoPath(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var info struct {
+		HTMLURL         string    `json:"html_url"`
+		StargazersCount int       `json:"stargazers_count"`
+		ForksCount      int       `json:"forks_count"`
+		OpenIssuesCount int       `json:"open_issues_count"`
+		PushedAt        time.Time `json:"pushed_at"`
+		Archived        bool      `json:"archived"`
+	}
+	base := "/repos/" + url.PathEscape(owner) + "/" + url.PathEscape(repo)
+	if _, err := c.get(ctx, base, nil, &info); err != nil {
+		return nil, err
+	}
+
+	meta := &RepoMetadata{
+		URL:        info.HTMLURL,
+		Stars:      info.StargazersCount,
+		Forks:      info.ForksCount,
+		OpenIssues: info.OpenIssuesCount,
+		LastPush:   info.PushedAt,
+		Archived:   info.Archived,
+	}
+	if meta.Contributors, err = c.count(ctx, base+"/contributors", url.Values{"anon": {"true"}}); err != nil {
+		meta.Unknown = append(meta.Unknown, SignalContributors)
+	}
+	since := time.Now().AddDate(0, 0, -commitWindowDays).UTC().Format(time.RFC3339)
+	if meta.RecentCommits, err = c.count(ctx, base+"/commits", url.Values{"since": {since}}); err != nil {
+		meta.Unknown = append(meta.Unknown, SignalCommits)
+	}
+	return meta, nil
+}
+
+// count returns the number of items in a paginated list endpoint
+func (c *GitHubClient) count(ctx context.Context, path string, query url.Values) (int, error) {
+	query.Set("per_page", "1")
+	var items []json.RawMessage
+	resp, err := c.get(ctx, path, query, &items)
+	if err != nil {
+		return 0, err
+	}
+	if resp.StatusCode == http.StatusNoContent {
+		// Empty repositories, but also contributor lists too large to count
+		return 0, fmt.Errorf("%s returned no count", path)
+	}
+	if n, ok := lastPage(resp.Header.Get("Link")); ok {
+		return n, nil
+	}
+	return len(items), nil
+}
+
+// get fetches path and decodes the JSON response into v. A 204
+// (contributors) or 409 (commits of an empty repository) leaves v
+// untouched.
+func (c *GitHubClient) get(ctx context.Context, path string, query url.Values, v interface{}) (*http.Response, error) {
+	u := c.BaseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	header := http.Header{}
+	header.Set("Accept", "application/vnd.github+json")
+	header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if c.Token != "" {
+		header.Set("Authorization", "Bearer "+c.Token)
+	}
+	return getJSON(ctx, c.client, u, header, v, http.StatusNoContent, http.StatusConflict)
+}
+
+var lastPageRE = regexp.MustCompile(`<([^>]*)>;\s*rel="last"`)
+
+// lastPage reads the page number of the rel="last" link of a Link header
+func lastPage(link string) (int, bool) {
+	m := lastPageRE.FindStringSubmatch(link)
+	if m == nil {
+		return 0, false
+	}
+	u, err := url.Parse(m[1])
+	if err != nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(u.Query().Get("page"))
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
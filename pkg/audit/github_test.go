@@ -0,0 +1,131 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGitHubClientFetchRepo(t *testing.T) {
+	pushed := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/acme/widget", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q", got)
+		}
+		fmt.Fprintf(w, `{"html_url":"https://github.com/acme/widget","stargazers_count":1200,"forks_count":80,"open_issues_count":7,"pushed_at":%q}`, pushed.Format(time.RFC3339))
+	})
+	mux.HandleFunc("/repos/acme/widget/contributors", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("per_page") != "1" || r.URL.Query().Get("anon") != "true" {
+			t.Errorf("contributors query = %s", r.URL.RawQuery)
+		}
+		w.Header().Set("Link", `<https://api.github.com/repositories/1/contributors?per_page=1&anon=true&page=2>; rel="next", <https://api.github.com/repositories/1/contributors?per_page=1&anon=true&page=42>; rel="last"`)
+		fmt.Fprint(w, `[{"login":"a"}]`)
+	})
+	mux.HandleFunc("/repos/acme/widget/commits", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("since") == "" {
+			t.Error("commits requested without since")
+		}
+		// A single page has no Link header
+		fmt.Fprint(w, `[{"sha":"1"}]`)
+	})
+	mux.HandleFunc("/repos/acme/huge", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"html_url":"https://github.com/acme/huge","stargazers_count":90000,"archived":true}`)
+	})
+	mux.HandleFunc("/repos/acme/huge/contributors", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"The history or contributor list is too large to list contributors for this repository via the API."}`, http.StatusForbidden)
+	})
+	mux.HandleFunc("/repos/acme/huge/commits", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`)
+	})
+	mux.HandleFunc("/repos/acme/empty", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"html_url":"https://github.com/acme/empty"}`)
+	})
+	mux.HandleFunc("/repos/acme/empty/contributors", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/repos/acme/empty/commits", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := NewGitHubClient(srv.URL+"/", "secret", srv.Client())
+
+	repo, err := client.FetchRepo(context.Background(), "acme/widget")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := RepoMetadata{
+		URL:           "https://github.com/acme/widget",
+		Stars:         1200,
+		Forks:         80,
+		OpenIssues:    7,
+		Contributors:  42,
+		RecentCommits: 1,
+		LastPush:      pushed,
+	}
+	if !reflect.DeepEqual(*repo, want) {
+		t.Errorf("FetchRepo() = %+v, want %+v", *repo, want)
+	}
+
+	empty, err := client.FetchRepo(context.Background(), "acme/empty")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if empty.Contributors != 0 || empty.RecentCommits != 0 {
+		t.Errorf("empty repository = %+v", *empty)
+	}
+
+	// A failed count does not throw away what is known about the repository
+	huge, err := client.FetchRepo(context.Background(), "acme/huge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !huge.Archived || huge.Stars != 90000 || !reflect.DeepEqual(huge.Unknown, []string{SignalContributors}) {
+		t.Errorf("huge repository = %+v", *huge)
+	}
+
+	if _, err := client.FetchRepo(context.Background(), "acme/missing"); err == nil {
+		t.Error("expected an error for a missing repository")
+	}
+}
+
+func TestRepoMetadataApply(t *testing.T) {
+	released := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	meta := &ModuleMetadata{LastCommitDate: released, VersionCount: 12}
+	repo := &RepoMetadata{
+		URL:           "https://github.com/acme/widget",
+		Stars:         10,
+		Contributors:  3,
+		RecentCommits: 45,
+		LastPush:      released.AddDate(1, 0, 0),
+		Archived:      true,
+	}
+	repo.apply(meta)
+
+	if meta.CommitFrequency != 15 {
+		t.Errorf("CommitFrequency = %v, want 15", meta.CommitFrequency)
+	}
+	if !meta.LastCommitDate.Equal(released) || !meta.LastPush.Equal(repo.LastPush) {
+		t.Errorf("LastCommitDate = %v, LastPush = %v, want the release date and the last push", meta.LastCommitDate, meta.LastPush)
+	}
+	if !meta.Archived {
+		t.Error("Archived not copied")
+	}
+	if meta.Stars != 10 || meta.Contributors != 3 || meta.VersionCount != 12 || meta.RepositoryURL != repo.URL {
+		t.Errorf("unexpected metadata %+v", meta)
+	}
+
+	// What the forge could not count stays unknown and undeclared
+	meta = &ModuleMetadata{}
+	(&RepoMetadata{Stars: 10, Unknown: []string{SignalContributors}}).apply(meta)
+	meta.declare("builtin", SignalRepository)
+	if !meta.IsUnknown(SignalContributors) || meta.provides(SignalContributors) || !meta.provides(SignalStars) {
+		t.Errorf("metadata = %+v, want the contributors unknown", meta)
+	}
+}
@@ -0,0 +1,387 @@
+package audit
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// GoModFile is the parsed content of a go.mod file
+type GoModFile struct {
+	Module     string           `json:"module"`
+	Deprecated string           `json:"deprecated,omitempty"` // from a "// Deprecated:" module comment
+	Go         string           `json:"go,omitempty"`
+	Toolchain  string           `json:"toolchain,omitempty"`
+	Godebug    []GodebugSetting `json:"godebug,omitempty"`
+	Require    []Requirement    `json:"require,omitempty"`
+	Exclude    []ModuleVersion  `json:"exclude,omitempty"`
+	Replace    []Replacement    `json:"replace,omitempty"`
+	Retract    []Retraction     `json:"retract,omitempty"`
+}
+
+// ModuleVersion identifies a module at a specific version
+type ModuleVersion struct {
+	Path    string `json:"path"`
+	Version string `json:"version,omitempty"`
+}
+
+func (mv ModuleVersion) String() string {
+	if mv.Version == "" {
+		return mv.Path
+	}
+	return mv.Path + "@" + mv.Version
+}
+
+// Requirement is a single require directive
+type Requirement struct {
+	Path     string `json:"path"`
+	Version  string `json:"version"`
+	Indirect bool   `json:"indirect"`
+}
+
+// Replacement is a single replace directive. Old.Version is empty when the
+// replacement applies to every version, New.Version is empty when the
+// replacement is a local directory.
+type Replacement struct {
+	Old ModuleVersion `json:"old"`
+	New ModuleVersion `json:"new"`
+}
+
+// Retraction is a single retract directive. Low and High are equal for a
+// single retracted version.
+type Retraction struct {
+	Low       string `json:"low"`
+	High      string `json:"high"`
+	Rationale string `json:"rationale,omitempty"`
+}
+
+// GodebugSetting is a single godebug key=value directive
+type GodebugSetting struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// ParseGoModFile reads and parses the go.mod file at path
+func ParseGoModFile(path string) (*GoModFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := ParseGoModData(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return f, nil
+}
+
+// ParseGoModData parses go.mod content. It understands single-line and block
+// forms of every directive, quoted paths and comments. Unknown directives are
+// ignored so newer go.mod files still parse.
+func ParseGoModData(data []byte) (*GoModFile, error) {
+	f := &GoModFile{}
+	err := parseModDirectives(data, f.add)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Replacement returns the replacement in effect for path@version. A
+// version-specific replacement wins over a path-wide one.
+func (f *GoModFile) Replacement(path, version string) (ModuleVersion, bool) {
+	var wildcard *Replacement
+	for i := range f.Replace {
+		r := &f.Replace[i]
+		if r.Old.Path != path {
+			continue
+		}
+		if r.Old.Version == version {
+			return r.New, true
+		}
+		if r.Old.Version == "" {
+			wildcard = r
+		}
+	}
+	if wildcard != nil {
+		return wildcard.New, true
+	}
+	return ModuleVersion{}, false
+}
+
+// IsExcluded reports whether path@version is excluded by an exclude directive
+func (f *GoModFile) IsExcluded(path, version string) bool {
+	for _, e := range f.Exclude {
+		if e.Path == path && e.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRetracted reports whether version falls in one of the retracted ranges
+// and returns the matching retraction
+func (f *GoModFile) IsRetracted(version string) (Retraction, bool) {
+	for _, r := range f.Retract {
+		if compareVersions(version, r.Low) >= 0 && compareVersions(version, r.High) <= 0 {
+			return r, true
+		}
+	}
+	return Retraction{}, false
+}
+
+func (f *GoModFile) add(d modDirective) error {
+	args := d.args
+	switch d.verb {
+	case "module":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: module module/path")
+		}
+		f.Module = args[0].text
+		f.Deprecated = parseDeprecation(d.comments())
+	case "go":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: go 1.23")
+		}
+		f.Go = args[0].text
+	case "toolchain":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: toolchain go1.23.0")
+		}
+		f.Toolchain = args[0].text
+	case "godebug":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: godebug key=value")
+		}
+		key, value, ok := strings.Cut(args[0].text, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("invalid godebug setting %q", args[0].text)
+		}
+		f.Godebug = append(f.Godebug, GodebugSetting{Key: key, Value: value})
+	case "require":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: require module/path v1.2.3")
+		}
+		f.Require = append(f.Require, Requirement{
+			Path:     args[0].text,
+			Version:  args[1].text,
+			Indirect: isIndirectComment(d.suffix),
+		})
+	case "exclude":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: exclude module/path v1.2.3")
+		}
+		f.Exclude = append(f.Exclude, ModuleVersion{Path: args[0].text, Version: args[1].text})
+	case "replace":
+		r, err := parseReplace(args)
+		if err != nil {
+			return err
+		}
+		f.Replace = append(f.Replace, r)
+	case "retract":
+		r, err := parseRetract(args)
+		if err != nil {
+			return err
+		}
+		r.Rationale = strings.Join(d.comments(), "\n")
+		f.Retract = append(f.Retract, r)
+	}
+	return nil
+}
+
+func parseReplace(args []modToken) (Replacement, error) {
+	arrow := -1
+	for i, a := range args {
+		if a.text == "=>" && !a.quoted {
+			arrow = i
+			break
+		}
+	}
+	if arrow < 1 || arrow > 2 || len(args)-arrow-1 < 1 || len(args)-arrow-1 > 2 {
+		return Replacement{}, fmt.Errorf("usage: replace module/path [v1.2.3] => other/module v1.4 or local/dir")
+	}
+	r := Replacement{Old: ModuleVersion{Path: args[0].text}}
+	if arrow == 2 {
+		r.Old.Version = args[1].text
+	}
+	r.New.Path = args[arrow+1].text
+	if len(args) == arrow+3 {
+		r.New.Version = args[arrow+2].text
+	} else if !isLocalPath(r.New.Path) {
+		return Replacement{}, fmt.Errorf("replacement module %s without version must be a directory path (rooted or starting with ./ or ../)", r.New.Path)
+	}
+	return r, nil
+}
+
+func parseRetract(args []modToken) (Retraction, error) {
+	if len(args) == 1 && !args[0].punct() {
+		return Retraction{Low: args[0].text, High: args[0].text}, nil
+	}
+	if len(args) == 5 && args[0].is("[") && args[2].is(",") && args[4].is("]") {
+		return Retraction{Low: args[1].text, High: args[3].text}, nil
+	}
+	return Retraction{}, fmt.Errorf("usage: retract v1.2.3 or retract [v1.0.0, v1.2.3]")
+}
+
+// isLocalPath reports whether a replacement target is a filesystem path
+func isLocalPath(path string) bool {
+	return strings.HasPrefix(path, "./") || strings.HasPrefix(path, "../") ||
+		strings.HasPrefix(path, "/") || path == "." || path == ".." ||
+		(len(path) >= 2 && path[1] == ':') // windows drive letter
+}
+
+func isIndirectComment(suffix string) bool {
+	return suffix == "indirect" || strings.HasPrefix(suffix, "indirect;")
+}
+
+// parseDeprecation extracts the message of a "Deprecated:" paragraph
+func parseDeprecation(comments []string) string {
+	for i, c := range comments {
+		if strings.HasPrefix(c, "Deprecated:") {
+			msg := []string{strings.TrimSpace(strings.TrimPrefix(c, "Deprecated:"))}
+			for _, rest := range comments[i+1:] {
+				if rest == "" {
+					break
+				}
+				msg = append(msg, rest)
+			}
+			return strings.TrimSpace(strings.Join(msg, "\n"))
+		}
+	}
+	return ""
+}
+
+// modToken is a single token of a go.mod line
+type modToken struct {
+	text   string
+	quoted bool
+}
+
+func (t modToken) punct() bool {
+	return !t.quoted && len(t.text) == 1 && strings.ContainsAny(t.text, "()[],")
+}
+
+func (t modToken) is(s string) bool {
+	return !t.quoted && t.text == s
+}
+
+// modDirective is one directive, or one entry of a directive block
+type modDirective struct {
+	verb   string
+	args   []modToken
+	before []string // comment lines directly above (or above the block)
+	suffix string   // comment on the same line
+}
+
+func (d modDirective) comments() []string {
+	if d.suffix == "" {
+		return d.before
+	}
+	return append(append([]string(nil), d.before...), d.suffix)
+}
+
+// parseModDirectives walks the directives of a go.mod style file (go.mod and
+// go.work share the syntax) and calls fn once per directive or block entry.
+func parseModDirectives(data []byte, fn func(modDirective) error) error {
+	var pending []string       // comment lines above the next directive
+	var block string           // verb of the block we are in, if any
+	var blockComments []string // comments attached to the block itself
+
+	for i, raw := range strings.Split(string(data), "\n") {
+		lineNo := i + 1
+		toks, comment, err := lexModLine(raw)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		if len(toks) == 0 {
+			if comment != "" || strings.Contains(raw, "//") {
+				pending = append(pending, comment)
+			} else {
+				pending = nil
+			}
+			continue
+		}
+
+		before := pending
+		pending = nil
+
+		if block != "" {
+			if len(toks) == 1 && toks[0].is(")") {
+				block, blockComments = "", nil
+				continue
+			}
+			if len(before) == 0 {
+				before = blockComments
+			}
+			if err := fn(modDirective{verb: block, args: toks, before: before, suffix: comment}); err != nil {
+				return fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			continue
+		}
+
+		verb := toks[0].text
+		args := toks[1:]
+		if len(args) >= 1 && args[0].is("(") {
+			switch {
+			case len(args) == 1:
+				block, blockComments = verb, modDirective{before: before, suffix: comment}.comments()
+			case len(args) == 2 && args[1].is(")"):
+				// empty block on a single line
+			default:
+				return fmt.Errorf("line %d: unexpected tokens after %s (", lineNo, verb)
+			}
+			continue
+		}
+		if err := fn(modDirective{verb: verb, args: args, before: before, suffix: comment}); err != nil {
+			return fmt.Errorf("line %d: %w", lineNo, err)
+		}
+	}
+
+	if block != "" {
+		return fmt.Errorf("unterminated %s block", block)
+	}
+	return nil
+}
+
+// lexModLine splits one line into tokens and returns the trailing comment
+// text without its leading slashes
+func lexModLine(line string) ([]modToken, string, error) {
+	var toks []modToken
+	i := 0
+	for i < len(line) {
+		c := line[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case strings.HasPrefix(line[i:], "//"):
+			return toks, strings.TrimSpace(line[i+2:]), nil
+		case strings.IndexByte("()[],", c) >= 0:
+			toks = append(toks, modToken{text: string(c)})
+			i++
+		case c == '"' || c == '`':
+			end := i + 1
+			for end < len(line) && line[end] != c {
+				if c == '"' && line[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(line) {
+				return nil, "", fmt.Errorf("unterminated quoted string")
+			}
+			s, err := strconv.Unquote(line[i : end+1])
+			if err != nil {
+				return nil, "", fmt.Errorf("invalid quoted string %s: %w", line[i:end+1], err)
+			}
+			toks = append(toks, modToken{text: s, quoted: true})
+			i = end + 1
+		default:
+			start := i
+			for i < len(line) && !strings.ContainsRune(" \t\r()[],\"`", rune(line[i])) && !strings.HasPrefix(line[i:], "//") {
+				i++
+			}
+			toks = append(toks, modToken{text: line[start:i]})
+		}
+	}
+	return toks, "", nil
+}
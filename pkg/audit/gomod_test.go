@@ -0,0 +1,146 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testGoMod = `// Deprecated: use example.com/new instead.
+module example.com/app
+
+go 1.22
+
+toolchain go1.22.3
+
+godebug (
+	default=go1.21
+	panicnil=1
+)
+
+require (
+	github.com/a/one v1.2.0
+	"github.com/b/two" v0.3.1 // indirect
+	github.com/c/three v1.0.0 // indirect; needed for tests
+)
+
+require github.com/d/four v2.0.0+incompatible
+
+exclude github.com/c/three v1.0.0
+
+replace github.com/a/one => github.com/fork/one v1.2.1 // pinned fork
+replace github.com/b/two v0.3.1 => ../two
+
+retract (
+	// Published by accident.
+	v1.0.1
+	[v1.1.0, v1.1.5] // broken build
+)
+`
+
+func TestParseGoModData(t *testing.T) {
+	f, err := ParseGoModData([]byte(testGoMod))
+	if err != nil {
+		t.Fatalf("ParseGoModData() error = %v", err)
+	}
+
+	if f.Module != "example.com/app" || f.Go != "1.22" || f.Toolchain != "go1.22.3" {
+		t.Errorf("module/go/toolchain = %q %q %q", f.Module, f.Go, f.Toolchain)
+	}
+	if f.Deprecated != "use example.com/new instead." {
+		t.Errorf("Deprecated = %q", f.Deprecated)
+	}
+	if len(f.Godebug) != 2 || f.Godebug[1] != (GodebugSetting{Key: "panicnil", Value: "1"}) {
+		t.Errorf("Godebug = %+v", f.Godebug)
+	}
+
+	wantRequire := []Requirement{
+		{Path: "github.com/a/one", Version: "v1.2.0"},
+		{Path: "github.com/b/two", Version: "v0.3.1", Indirect: true},
+		{Path: "github.com/c/three", Version: "v1.0.0", Indirect: true},
+		{Path: "github.com/d/four", Version: "v2.0.0+incompatible"},
+	}
+	if len(f.Require) != len(wantRequire) {
+		t.Fatalf("Require = %+v", f.Require)
+	}
+	for i, want := range wantRequire {
+		if f.Require[i] != want {
+			t.Errorf("Require[%d] = %+v, want %+v", i, f.Require[i], want)
+		}
+	}
+
+	if !f.IsExcluded("github.com/c/three", "v1.0.0") || f.IsExcluded("github.com/c/three", "v1.0.1") {
+		t.Errorf("IsExcluded mismatch, Exclude = %+v", f.Exclude)
+	}
+
+	if r, ok := f.Replacement("github.com/a/one", "v1.2.0"); !ok || r.String() != "github.com/fork/one@v1.2.1" {
+		t.Errorf("Replacement(a/one) = %v, %v", r, ok)
+	}
+	if r, ok := f.Replacement("github.com/b/two", "v0.3.1"); !ok || r.Path != "../two" || r.Version != "" {
+		t.Errorf("Replacement(b/two) = %v, %v", r, ok)
+	}
+	if _, ok := f.Replacement("github.com/b/two", "v0.3.2"); ok {
+		t.Errorf("Replacement(b/two@v0.3.2) should not match a version-specific replace")
+	}
+
+	tests := []struct {
+		version   string
+		retracted bool
+		rationale string
+	}{
+		{"v1.0.1", true, "Published by accident."},
+		{"v1.1.3", true, "broken build"},
+		{"v1.1.5", true, "broken build"},
+		{"v1.1.6", false, ""},
+		{"v1.0.0", false, ""},
+	}
+	for _, tt := range tests {
+		r, ok := f.IsRetracted(tt.version)
+		if ok != tt.retracted || r.Rationale != tt.rationale {
+			t.Errorf("IsRetracted(%s) = %+v, %v, want %v %q", tt.version, r, ok, tt.retracted, tt.rationale)
+		}
+	}
+}
+
+func TestParseGoModDataErrors(t *testing.T) {
+	tests := []string{
+		"require (\n\tgithub.com/a/one v1.0.0\n",
+		"replace github.com/a/one => github.com/b/one\n",
+		"require github.com/a/one\n",
+		"module \"example.com/app\n",
+	}
+	for _, data := range tests {
+		if _, err := ParseGoModData([]byte(data)); err == nil {
+			t.Errorf("ParseGoModData(%q) expected error", data)
+		}
+	}
+}
+
+func TestParseGoMod(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "go.mod")
+	if err := os.WriteFile(path, []byte(testGoMod), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	modules, err := ParseGoMod(path)
+	if err != nil {
+		t.Fatalf("ParseGoMod() error = %v", err)
+	}
+	// main module + 4 requirements - 1 excluded
+	if len(modules) != 4 {
+		t.Fatalf("ParseGoMod() returned %d modules: %+v", len(modules), modules)
+	}
+	if !modules[0].Main || modules[0].Path != "example.com/app" {
+		t.Errorf("first module should be the main module, got %+v", modules[0])
+	}
+	if r := modules[1].Replace; r == nil || r.Path != "github.com/fork/one" || r.Version != "v1.2.1" {
+		t.Errorf("github.com/a/one replacement = %+v", r)
+	}
+	if r := modules[2].Replace; r == nil || r.Dir != filepath.Join(filepath.Dir(dir), "two") {
+		t.Errorf("github.com/b/two replacement = %+v", r)
+	}
+	if modules[3].Path != "github.com/d/four" || modules[3].Indirect {
+		t.Errorf("unexpected module %+v", modules[3])
+	}
+}
@@ -0,0 +1,202 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultGoProxy is the go command's default GOPROXY
+const defaultGoProxy = "https://proxy.golang.org,direct"
+
+// errNotFound is a proxy answer that lets a comma-separated GOPROXY list
+// fall back to the next entry
+var errNotFound = errors.New("not found")
+
+// proxyEntry is one element of a GOPROXY list
+type proxyEntry struct {
+	// URL is a proxy URL (https:// or file://), "direct" or "off"
+	URL string
+	// AnyError is set for entries followed by "|", which fall back on any
+	// error instead of only on not found
+	AnyError bool
+}
+
+// parseGoProxy parses a GOPROXY list. Like the go command, entries without
+// a scheme get https://, and entries after direct or off are never reached.
+func parseGoProxy(s string) ([]proxyEntry, error) {
+	var entries []proxyEntry
+	for s != "" {
+		i := strings.IndexAny(s, ",|")
+		elem, anyError := s, false
+		if i >= 0 {
+			elem, anyError, s = s[:i], s[i] == '|', s[i+1:]
+		} else {
+			s = ""
+		}
+		elem = strings.TrimSpace(elem)
+		switch {
+		case elem == "":
+			continue
+		case elem == "direct" || elem == "off":
+			return append(entries, proxyEntry{URL: elem}), nil
+		case !strings.Contains(elem, ":/") && !filepath.IsAbs(elem):
+			elem = "https://" + elem
+		}
+		u, err := url.Parse(elem)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http" && u.Scheme != "file") {
+			return nil, fmt.Errorf("invalid GOPROXY entry %q", redactURL(elem))
+		}
+		entries = append(entries, proxyEntry{URL: strings.TrimSuffix(elem, "/"), AnyError: anyError})
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("GOPROXY list is empty")
+	}
+	return entries, nil
+}
+
+// matchPrefixPatterns reports whether a GOPRIVATE-style list of glob
+// patterns matches a prefix of the module path, e.g. *.corp.example.com
+// matches git.corp.example.com/team/mod
+func matchPrefixPatterns(patterns, modulePath string) bool {
+	for _, pattern := range strings.Split(patterns, ",") {
+		pattern = strings.Trim(strings.TrimSpace(pattern), "/")
+		if pattern == "" {
+			continue
+		}
+		n := strings.Count(pattern, "/") + 1
+		elems := strings.SplitN(modulePath, "/", n+1)
+		if len(elems) < n {
+			continue
+		}
+		if ok, _ := path.Match(pattern, strings.Join(elems[:n], "/")); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// goProxySettings returns the GOPROXY list and GONOPROXY patterns from the
+// config, falling back to the go environment. GONOPROXY defaults to
+// GOPRIVATE.
+func goProxySettings(config AuditConfig) ([]proxyEntry, string) {
+	list := firstNonEmpty(config.GoProxy, goEnv("GOPROXY"), defaultGoProxy)
+	proxies, err := parseGoProxy(list)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v, using %s\n", err, defaultGoProxy)
+		proxies, _ = parseGoProxy(defaultGoProxy)
+	}
+	noProxy := firstNonEmpty(config.GoNoProxy, goEnv("GONOPROXY"), config.GoPrivate, goEnv("GOPRIVATE"))
+	return proxies, noProxy
+}
+
+// proxiesFor returns the proxies to ask for a module. Modules matching
+// GONOPROXY are never sent to a proxy.
+func (f *Fetcher) proxiesFor(modulePath string) []proxyEntry {
+	if f.noProxy != "" && matchPrefixPatterns(f.noProxy, modulePath) {
+		return []proxyEntry{{URL: "direct"}}
+	}
+	return f.proxies
+}
+
+// proxyFetch fetches a file below the module's escaped path from the
+// proxies in turn, falling back like the go command. There is no version
+// control access here, so "direct" serves what the go command already
+// downloaded into the module cache. cached is an expired entry to
+// revalidate, if any.
+func (f *Fetcher) proxyFetch(ctx context.Context, proxies []proxyEntry, escaped, file string, cached *cacheEntry) (*cacheEntry, error) {
+	var lastErr error
+	for _, p := range proxies {
+		var data []byte
+		var err error
+		modCache := false
+		switch {
+		case p.URL == "off":
+			return nil, fmt.Errorf("module lookup disabled by GOPROXY=off")
+		case p.URL == "direct":
+			dir := goModCacheDir()
+			if dir == "" {
+				return nil, fmt.Errorf("no module cache for direct access")
+			}
+			data, err = readProxyFile(filepath.Join(dir, "cache", "download"), escaped, file)
+			modCache = true
+		case strings.HasPrefix(p.URL, "file://"):
+			u, _ := url.Parse(p.URL)
+			data, err = readProxyFile(filepath.FromSlash(u.Path), escaped, file)
+		default:
+			entry, err := f.httpGet(ctx, p.URL+"/"+escaped+"/"+file, cached)
+			if err == nil {
+				return entry, nil
+			}
+			lastErr = err
+			if !p.AnyError && !errors.Is(err, errNotFound) {
+				return nil, err
+			}
+			continue
+		}
+		if err == nil {
+			return &cacheEntry{Fetched: time.Now(), Data: data, ModCache: modCache}, nil
+		}
+		lastErr = err
+		if !p.AnyError && !errors.Is(err, errNotFound) {
+			return nil, err
+		}
+	}
+	return nil, lastErr
+}
+
+// readProxyFile reads a file from a directory in the module proxy layout
+func readProxyFile(dir, escaped, file string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(escaped), filepath.FromSlash(file)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%s/%s: %w", escaped, file, errNotFound)
+	}
+	return data, err
+}
+
+// goEnv reads a go environment variable the way the go command does: from
+// the process environment, else from the file 'go env -w' writes to
+func goEnv(key string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	file := os.Getenv("GOENV")
+	if file == "off" {
+		return ""
+	}
+	if file == "" {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return ""
+		}
+		file = filepath.Join(dir, "go", "env")
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if k, v, ok := strings.Cut(scanner.Text(), "="); ok && strings.TrimSpace(k) == key {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,106 @@
+package audit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseGoProxy(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []proxyEntry
+		wantErr bool
+	}{
+		{"https://proxy.golang.org,direct", []proxyEntry{{URL: "https://proxy.golang.org"}, {URL: "direct"}}, false},
+		{"athens.corp.example.com|https://proxy.golang.org/", []proxyEntry{{URL: "https://athens.corp.example.com", AnyError: true}, {URL: "https://proxy.golang.org"}}, false},
+		{"file:///srv/goproxy,off,https://ignored.example.com", []proxyEntry{{URL: "file:///srv/goproxy"}, {URL: "off"}}, false},
+		{"direct", []proxyEntry{{URL: "direct"}}, false},
+		{" , ", nil, true},
+		{"/srv/goproxy", nil, true},
+		{"ftp://proxy.example.com", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseGoProxy(tt.in)
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseGoProxy(%q) = %+v, %v", tt.in, got, err)
+		}
+	}
+}
+
+func TestMatchPrefixPatterns(t *testing.T) {
+	tests := []struct {
+		patterns string
+		path     string
+		want     bool
+	}{
+		{"*.corp.example.com", "git.corp.example.com/team/mod", true},
+		{"*.corp.example.com", "corp.example.com/team/mod", false},
+		{"github.com/acme", "github.com/acme/private/v2", true},
+		{"github.com/acme", "github.com/acmeco/public", false},
+		{"github.com/other, github.com/acme/*", "github.com/acme/private", true},
+		{"github.com/acme/private/deep", "github.com/acme/private", false},
+		{"", "github.com/acme/private", false},
+	}
+	for _, tt := range tests {
+		if got := matchPrefixPatterns(tt.patterns, tt.path); got != tt.want {
+			t.Errorf("matchPrefixPatterns(%q, %q) = %v, want %v", tt.patterns, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestProxyFallback(t *testing.T) {
+	missing := httptest.NewServer(http.NotFoundHandler())
+	defer missing.Close()
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusBadGateway)
+	}))
+	defer broken.Close()
+	var requests []string
+	good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+		w.Write([]byte("v1.0.0\nv1.1.0\n"))
+	}))
+	defer good.Close()
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "example.com", "!acme", "@v"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "example.com", "!acme", "@v", "list"), []byte("v0.1.0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		config  AuditConfig
+		path    string
+		want    []string
+		wantErr bool
+	}{
+		{"not found falls back", AuditConfig{GoProxy: missing.URL + "," + good.URL}, "example.com/mod", []string{"v1.0.0", "v1.1.0"}, false},
+		{"comma stops on other errors", AuditConfig{GoProxy: broken.URL + "," + good.URL, MaxRetries: -1}, "example.com/mod", nil, true},
+		{"pipe falls back on any error", AuditConfig{GoProxy: broken.URL + "|" + good.URL, MaxRetries: -1}, "example.com/mod", []string{"v1.0.0", "v1.1.0"}, false},
+		{"file proxy", AuditConfig{GoProxy: "file://" + filepath.ToSlash(dir)}, "example.com/Acme", []string{"v0.1.0"}, false},
+		{"off", AuditConfig{GoProxy: "off"}, "example.com/mod", nil, true},
+		{"private module never reaches the proxy", AuditConfig{GoProxy: good.URL + ",off", GoPrivate: "example.com/private"}, "example.com/private/mod", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests = nil
+			t.Setenv("GOMODCACHE", t.TempDir())
+			f := NewFetcher(tt.config)
+			got, err := f.fetchVersionList(context.Background(), tt.path)
+			if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fetchVersionList() = %v, %v", got, err)
+			}
+			if tt.config.GoPrivate != "" && len(requests) > 0 {
+				t.Errorf("private module sent to the proxy: %v", requests)
+			}
+		})
+	}
+}
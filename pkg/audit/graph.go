@@ -0,0 +1,285 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// DependencyGraph is the module requirement graph with edges parent→child.
+// Nodes are module paths; each path stands for the version the build
+// selects, so requirements of versions that lose selection are dropped.
+type DependencyGraph struct {
+	// Roots are the main modules the graph starts from
+	Roots []string
+
+	versions map[string]string
+	edges    map[string]map[string]bool
+}
+
+// NewDependencyGraph returns an empty graph rooted at the given main modules
+func NewDependencyGraph(roots ...string) *DependencyGraph {
+	g := &DependencyGraph{
+		versions: make(map[string]string),
+		edges:    make(map[string]map[string]bool),
+	}
+	for _, r := range roots {
+		g.Roots = append(g.Roots, r)
+		g.versions[r] = ""
+	}
+	return g
+}
+
+// AddModule records path at its selected version
+func (g *DependencyGraph) AddModule(path, version string) {
+	if _, ok := g.versions[path]; !ok || version != "" {
+		g.versions[path] = version
+	}
+}
+
+// AddEdge records that parent requires child
+func (g *DependencyGraph) AddEdge(parent, child string) {
+	if parent == child {
+		return
+	}
+	if _, ok := g.versions[parent]; !ok {
+		g.versions[parent] = ""
+	}
+	if _, ok := g.versions[child]; !ok {
+		g.versions[child] = ""
+	}
+	if g.edges[parent] == nil {
+		g.edges[parent] = make(map[string]bool)
+	}
+	g.edges[parent][child] = true
+}
+
+// Version returns the selected version of path
+func (g *DependencyGraph) Version(path string) string {
+	return g.versions[path]
+}
+
+// Modules returns every module path in the graph, roots included, sorted
+func (g *DependencyGraph) Modules() []string {
+	paths := make([]string, 0, len(g.versions))
+	for p := range g.versions {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Requirements returns the direct requirements of path, sorted
+func (g *DependencyGraph) Requirements(path string) []string {
+	children := make([]string, 0, len(g.edges[path]))
+	for c := range g.edges[path] {
+		children = append(children, c)
+	}
+	sort.Strings(children)
+	return children
+}
+
+// Dependencies returns every module path reachable from path, excluding
+// path itself, sorted
+func (g *DependencyGraph) Dependencies(path string) []string {
+	depth := g.walk([]string{path}, "")
+	deps := make([]string, 0, len(depth))
+	for p := range depth {
+		if p != path {
+			deps = append(deps, p)
+		}
+	}
+	sort.Strings(deps)
+	return deps
+}
+
+// MaxDepth returns the number of levels below path: 1 when it only has
+// direct requirements, 0 when it has none. Module graphs may contain
+// cycles, so each module counts at the shortest distance it is reached.
+func (g *DependencyGraph) MaxDepth(path string) int {
+	deepest := 0
+	for _, d := range g.walk([]string{path}, "") {
+		if d > deepest {
+			deepest = d
+		}
+	}
+	return deepest
+}
+
+// ExclusiveDependencies returns the modules that are only in the graph
+// because of path: removing path would drop them from every root's
+// dependency closure.
+func (g *DependencyGraph) ExclusiveDependencies(path string) []string {
+	with := g.walk(g.Roots, "")
+	if _, ok := with[path]; !ok {
+		return nil
+	}
+	without := g.walk(g.Roots, path)
+
+	var exclusive []string
+	for p := range with {
+		if _, ok := without[p]; !ok && p != path {
+			exclusive = append(exclusive, p)
+		}
+	}
+	sort.Strings(exclusive)
+	return exclusive
+}
+
+// RequiredBy returns, for every dependency in the graph, the sorted main
+// modules whose dependency closure includes it
+func (g *DependencyGraph) RequiredBy() map[string][]string {
+	requiredBy := make(map[string][]string)
+	for _, root := range g.Roots {
+		for path := range g.walk([]string{root}, "") {
+			if path != root {
+				requiredBy[path] = append(requiredBy[path], root)
+			}
+		}
+	}
+	for _, roots := range requiredBy {
+		sort.Strings(roots)
+	}
+	return requiredBy
+}
+
+// walk does a breadth-first traversal from start and returns the distance
+// of every reached module. The skip module is never entered.
+func (g *DependencyGraph) walk(start []string, skip string) map[string]int {
+	depth := make(map[string]int)
+	var queue []string
+	for _, s := range start {
+		if s == skip {
+			continue
+		}
+		if _, ok := depth[s]; !ok {
+			depth[s] = 0
+			queue = append(queue, s)
+		}
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for child := range g.edges[cur] {
+			if child == skip {
+				continue
+			}
+			if _, seen := depth[child]; !seen {
+				depth[child] = depth[cur] + 1
+				queue = append(queue, child)
+			}
+		}
+	}
+	return depth
+}
+
+// GetDependencyGraph builds the dependency graph from 'go mod graph'
+func GetDependencyGraph(ctx context.Context, projectPath string) (*DependencyGraph, error) {
+	return getDependencyGraph(ctx, projectPath, goCommandEnv(projectPath))
+}
+
+// getDependencyGraph is GetDependencyGraph with the go command's environment
+func getDependencyGraph(ctx context.Context, projectPath string, env []string) (*DependencyGraph, error) {
+	cmd := exec.CommandContext(ctx, "go", "mod", "graph")
+	cmd.Dir = projectPath
+	cmd.Env = env
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to run go mod graph: %w, stderr: %s", err, stderr.String())
+	}
+
+	return ParseModGraph(&stdout)
+}
+
+// ParseModGraph parses 'go mod graph' output. Each line is "parent child"
+// where both are path@version, except main modules which have no version.
+// The highest version of each path is taken as the selected one, which is
+// what minimal version selection picks for the graph.
+func ParseModGraph(r io.Reader) (*DependencyGraph, error) {
+	type edge struct{ parent, child ModuleVersion }
+	var edges []edge
+	selected := make(map[string]string)
+	var roots []string
+	seenRoot := make(map[string]bool)
+
+	note := func(mv ModuleVersion) {
+		if mv.Version == "" {
+			if !seenRoot[mv.Path] {
+				seenRoot[mv.Path] = true
+				roots = append(roots, mv.Path)
+			}
+			return
+		}
+		if cur, ok := selected[mv.Path]; !ok || compareVersions(mv.Version, cur) > 0 {
+			selected[mv.Path] = mv.Version
+		}
+	}
+
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected 2 fields, got %d", lineNo, len(fields))
+		}
+		parent, child := splitModuleVersion(fields[0]), splitModuleVersion(fields[1])
+		// go@1.21 and toolchain@go1.21 pseudo-modules are not dependencies
+		if isGoPseudoModule(parent.Path) || isGoPseudoModule(child.Path) {
+			continue
+		}
+		note(parent)
+		note(child)
+		edges = append(edges, edge{parent, child})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	g := NewDependencyGraph(roots...)
+	for path, version := range selected {
+		if !seenRoot[path] {
+			g.AddModule(path, version)
+		}
+	}
+	for _, e := range edges {
+		// Only the selected version's requirements make it into the build
+		if e.parent.Version != "" && e.parent.Version != selected[e.parent.Path] {
+			continue
+		}
+		g.AddEdge(e.parent.Path, e.child.Path)
+	}
+
+	// Modules only required by versions that lost selection are not part of
+	// the build
+	reachable := g.walk(g.Roots, "")
+	for path := range g.versions {
+		if _, ok := reachable[path]; !ok {
+			delete(g.versions, path)
+			delete(g.edges, path)
+		}
+	}
+	return g, nil
+}
+
+func splitModuleVersion(s string) ModuleVersion {
+	if i := strings.LastIndexByte(s, '@'); i > 0 {
+		return ModuleVersion{Path: s[:i], Version: s[i+1:]}
+	}
+	return ModuleVersion{Path: s}
+}
+
+func isGoPseudoModule(path string) bool {
+	return path == "go" || path == "toolchain"
+}
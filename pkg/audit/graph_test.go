@@ -0,0 +1,68 @@
+package audit
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testModGraph = `example.com/app example.com/a@v1.0.0
+example.com/app example.com/b@v1.1.0
+example.com/app go@1.21
+go@1.21 toolchain@go1.21
+example.com/a@v1.0.0 example.com/c@v1.0.0
+example.com/a@v1.0.0 example.com/d@v1.0.0
+example.com/b@v1.1.0 example.com/d@v1.2.0
+example.com/b@v1.0.0 example.com/old@v1.0.0
+example.com/c@v1.0.0 example.com/e@v1.0.0
+example.com/e@v1.0.0 example.com/c@v1.0.0
+`
+
+func TestParseModGraph(t *testing.T) {
+	g, err := ParseModGraph(strings.NewReader(testModGraph))
+	if err != nil {
+		t.Fatalf("ParseModGraph() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(g.Roots, []string{"example.com/app"}) {
+		t.Errorf("Roots = %v", g.Roots)
+	}
+	if v := g.Version("example.com/d"); v != "v1.2.0" {
+		t.Errorf("selected version of d = %q, want v1.2.0", v)
+	}
+	// b@v1.0.0 is not selected, so its requirement on old must be dropped
+	for _, p := range g.Dependencies("example.com/app") {
+		if p == "example.com/old" || p == "go" || p == "toolchain" {
+			t.Errorf("unexpected module %q in dependency closure", p)
+		}
+	}
+}
+
+func TestCalculateFootprint(t *testing.T) {
+	g, err := ParseModGraph(strings.NewReader(testModGraph))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want FootprintMetrics
+	}{
+		// a -> c <-> e, a -> d (d is shared with b)
+		{"example.com/a", FootprintMetrics{TransitiveDeps: 3, MaxDepth: 2, ExclusiveDeps: 2, DependencyWeight: 3.0 / 5.0}},
+		{"example.com/b", FootprintMetrics{TransitiveDeps: 1, MaxDepth: 1, ExclusiveDeps: 0, DependencyWeight: 1.0 / 5.0}},
+		{"example.com/c", FootprintMetrics{TransitiveDeps: 1, MaxDepth: 1, ExclusiveDeps: 1, DependencyWeight: 2.0 / 5.0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got := CalculateFootprint(Module{Path: tt.path}, g)
+			if got != tt.want {
+				t.Errorf("CalculateFootprint(%s) = %+v, want %+v", tt.path, got, tt.want)
+			}
+		})
+	}
+
+	if got := CalculateFootprint(Module{Path: "example.com/a"}, nil); got != (FootprintMetrics{}) {
+		t.Errorf("CalculateFootprint without graph = %+v, want zero", got)
+	}
+}
@@ -0,0 +1,521 @@
+package audit
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"debug/buildinfo"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strings"
+)
+
+// ImageBinary is a Go binary found in a container image
+type ImageBinary struct {
+	// Path is the absolute path of the binary in the image filesystem
+	Path string
+	// Platform is "os/arch[/variant]" of the image the binary belongs to
+	Platform string
+	Info     *debug.BuildInfo
+}
+
+// BinaryAudit holds the results for one binary of an image audit
+type BinaryAudit struct {
+	Path        string         `json:"path"`
+	Platform    string         `json:"platform,omitempty"`
+	MainModule  string         `json:"main_module"`
+	MainVersion string         `json:"main_version,omitempty"`
+	GoVersion   string         `json:"go_version,omitempty"`
+	Results     []ModuleHealth `json:"results"`
+}
+
+// ImageAudit is the result of auditing every Go binary in an image
+type ImageAudit struct {
+	Image    string        `json:"image"`
+	Binaries []BinaryAudit `json:"binaries"`
+	// Rollup lists every dependency version once; RequiredBy names the
+	// binaries that link it
+	Rollup []ModuleHealth `json:"rollup"`
+}
+
+// name identifies the binary in rollups. The platform is only included for
+// multi-platform images, where the same path exists once per platform.
+func (b BinaryAudit) name(multiPlatform bool) string {
+	if multiPlatform && b.Platform != "" {
+		return b.Platform + ":" + b.Path
+	}
+	return b.Path
+}
+
+// AuditImage audits every Go binary in an OCI image layout directory or a
+// 'docker save' tarball. Binaries share one Fetcher, so a module linked into
+// several of them is only fetched once.
+func AuditImage(ctx context.Context, config AuditConfig, image string) (*ImageAudit, error) {
+	binaries, err := ReadImageBinaries(image)
+	if err != nil {
+		return nil, err
+	}
+
+	fetcher := auditFetcher(config)
+	platforms := make(map[string]bool)
+	result := &ImageAudit{Image: image}
+	for _, bin := range binaries {
+		platforms[bin.Platform] = true
+		modules := ModulesFromBuildInfo(bin.Info)
+		result.Binaries = append(result.Binaries, BinaryAudit{
+			Path:        bin.Path,
+			Platform:    bin.Platform,
+			MainModule:  bin.Info.Main.Path,
+			MainVersion: bin.Info.Main.Version,
+			GoVersion:   bin.Info.GoVersion,
+			Results:     auditModuleList(ctx, config, fetcher, modules, nil, linkedReachability(modules, bin.Platform)),
+		})
+	}
+
+	groups := make([]resultGroup, len(result.Binaries))
+	for i, b := range result.Binaries {
+		groups[i] = resultGroup{name: b.name(len(platforms) > 1), results: b.Results}
+	}
+	result.Rollup = rollup(groups)
+	return result, nil
+}
+
+// ReadImageBinaries finds the Go binaries in an OCI image layout directory
+// or a 'docker save' tarball and reads their build info. Layers are applied
+// in order, so files that a later layer deletes or replaces are not
+// reported. Binaries are sorted by platform and path.
+func ReadImageBinaries(image string) ([]ImageBinary, error) {
+	src, err := openImageSource(image)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	manifests, err := readImageManifests(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image %s: %w", image, err)
+	}
+
+	var binaries []ImageBinary
+	for _, m := range manifests {
+		found, err := readLayerBinaries(src, m.layers)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read image %s: %w", image, err)
+		}
+		for _, bin := range found {
+			bin.Platform = m.platform
+			binaries = append(binaries, bin)
+		}
+	}
+
+	sort.Slice(binaries, func(i, j int) bool {
+		if binaries[i].Platform != binaries[j].Platform {
+			return binaries[i].Platform < binaries[j].Platform
+		}
+		return binaries[i].Path < binaries[j].Path
+	})
+	return binaries, nil
+}
+
+// imageManifest is one platform's image: its layer blobs, bottom first
+type imageManifest struct {
+	platform string
+	layers   []string
+}
+
+// OCI image layout and docker save documents, only the fields we need
+type ociDescriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Platform  *struct {
+		OS           string `json:"os"`
+		Architecture string `json:"architecture"`
+		Variant      string `json:"variant"`
+	} `json:"platform"`
+}
+
+type ociIndex struct {
+	MediaType string          `json:"mediaType"`
+	Manifests []ociDescriptor `json:"manifests"`
+}
+
+type ociManifest struct {
+	MediaType string          `json:"mediaType"`
+	Config    ociDescriptor   `json:"config"`
+	Layers    []ociDescriptor `json:"layers"`
+}
+
+type imageConfig struct {
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+	Variant      string `json:"variant"`
+}
+
+type dockerManifest struct {
+	Config string   `json:"Config"`
+	Layers []string `json:"Layers"`
+}
+
+// readImageManifests lists the images in the source. An OCI layout
+// (index.json) is preferred; newer docker save tarballs contain both.
+func readImageManifests(src imageSource) ([]imageManifest, error) {
+	if data, err := readImageFile(src, "index.json"); err == nil {
+		var index ociIndex
+		if err := json.Unmarshal(data, &index); err != nil {
+			return nil, fmt.Errorf("index.json: %w", err)
+		}
+		var manifests []imageManifest
+		seen := make(map[string]bool)
+		if err := collectOCIManifests(src, index.Manifests, seen, &manifests); err != nil {
+			return nil, err
+		}
+		return manifests, nil
+	}
+
+	data, err := readImageFile(src, "manifest.json")
+	if err != nil {
+		return nil, fmt.Errorf("neither index.json nor manifest.json found")
+	}
+	var docker []dockerManifest
+	if err := json.Unmarshal(data, &docker); err != nil {
+		return nil, fmt.Errorf("manifest.json: %w", err)
+	}
+	var manifests []imageManifest
+	for _, d := range docker {
+		manifests = append(manifests, imageManifest{
+			platform: configPlatform(src, d.Config),
+			layers:   d.Layers,
+		})
+	}
+	return manifests, nil
+}
+
+// collectOCIManifests follows descriptors through nested image indexes
+func collectOCIManifests(src imageSource, descs []ociDescriptor, seen map[string]bool, out *[]imageManifest) error {
+	for _, desc := range descs {
+		if seen[desc.Digest] {
+			continue
+		}
+		seen[desc.Digest] = true
+		// Attestations and other artifacts are listed as unknown/unknown
+		if desc.Platform != nil && desc.Platform.OS == "unknown" {
+			continue
+		}
+
+		blob, err := blobPath(desc.Digest)
+		if err != nil {
+			return err
+		}
+		data, err := readImageFile(src, blob)
+		if err != nil {
+			return err
+		}
+
+		if strings.Contains(desc.MediaType, "index") || strings.Contains(desc.MediaType, "manifest.list") {
+			var index ociIndex
+			if err := json.Unmarshal(data, &index); err != nil {
+				return fmt.Errorf("%s: %w", desc.Digest, err)
+			}
+			if err := collectOCIManifests(src, index.Manifests, seen, out); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var manifest ociManifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return fmt.Errorf("%s: %w", desc.Digest, err)
+		}
+		m := imageManifest{}
+		if config, err := blobPath(manifest.Config.Digest); err == nil {
+			m.platform = configPlatform(src, config)
+		}
+		for _, layer := range manifest.Layers {
+			// Only filesystem layers, not e.g. in-toto attestations
+			if layer.MediaType != "" && !strings.Contains(layer.MediaType, "tar") {
+				continue
+			}
+			blob, err := blobPath(layer.Digest)
+			if err != nil {
+				return err
+			}
+			m.layers = append(m.layers, blob)
+		}
+		*out = append(*out, m)
+	}
+	return nil
+}
+
+// configPlatform reads "os/arch[/variant]" from an image config, or ""
+func configPlatform(src imageSource, name string) string {
+	data, err := readImageFile(src, name)
+	if err != nil {
+		return ""
+	}
+	var config imageConfig
+	if err := json.Unmarshal(data, &config); err != nil || config.OS == "" {
+		return ""
+	}
+	platform := config.OS + "/" + config.Architecture
+	if config.Variant != "" {
+		platform += "/" + config.Variant
+	}
+	return platform
+}
+
+// blobPath maps a digest like sha256:abc to blobs/sha256/abc
+func blobPath(digest string) (string, error) {
+	alg, hex, ok := strings.Cut(digest, ":")
+	if !ok || alg == "" || hex == "" || strings.ContainsAny(digest, "/\\") {
+		return "", fmt.Errorf("invalid digest %q", digest)
+	}
+	return "blobs/" + alg + "/" + hex, nil
+}
+
+// readLayerBinaries applies the layers in order and returns the Go binaries
+// of the resulting filesystem. The first pass only tracks which layer
+// provides each executable file; the second reads just those files.
+func readLayerBinaries(src imageSource, layers []string) ([]ImageBinary, error) {
+	files := make(map[string]int) // executable path → providing layer
+	for i, layer := range layers {
+		err := walkLayer(src, layer, func(hdr *tar.Header, name string, _ io.Reader) error {
+			dir, base := path.Split(name)
+			switch {
+			case base == ".wh..wh..opq":
+				// Opaque directory: hide everything lower layers put in it
+				removeLowerFiles(files, strings.TrimSuffix(dir, "/"), i, false)
+			case strings.HasPrefix(base, ".wh."):
+				removeLowerFiles(files, dir+strings.TrimPrefix(base, ".wh."), i, true)
+			case hdr.Typeflag == tar.TypeReg && hdr.Mode&0o111 != 0:
+				files[name] = i
+			default:
+				// Anything else at this path replaces an executable, and a
+				// non-directory also hides what was below a directory
+				delete(files, name)
+				if hdr.Typeflag != tar.TypeDir {
+					removeLowerFiles(files, name, i, false)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("layer %s: %w", layer, err)
+		}
+	}
+
+	byLayer := make(map[int]bool)
+	for _, i := range files {
+		byLayer[i] = true
+	}
+
+	found := make(map[string]*debug.BuildInfo)
+	for i, layer := range layers {
+		if !byLayer[i] {
+			continue
+		}
+		err := walkLayer(src, layer, func(hdr *tar.Header, name string, r io.Reader) error {
+			if provider, ok := files[name]; !ok || provider != i || hdr.Typeflag != tar.TypeReg {
+				return nil
+			}
+			info, err := readELFBuildInfo(r)
+			if err != nil {
+				return err
+			}
+			if info != nil {
+				found[name] = info
+			} else {
+				// A later entry for the same path may have replaced a binary
+				delete(found, name)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("layer %s: %w", layer, err)
+		}
+	}
+
+	var binaries []ImageBinary
+	for name, info := range found {
+		binaries = append(binaries, ImageBinary{Path: name, Info: info})
+	}
+	return binaries, nil
+}
+
+// removeLowerFiles drops p, and with children also everything below it, when
+// it was provided by a layer before layer
+func removeLowerFiles(files map[string]int, p string, layer int, self bool) {
+	prefix := p + "/"
+	for name, i := range files {
+		if i >= layer {
+			continue
+		}
+		if (self && name == p) || strings.HasPrefix(name, prefix) {
+			delete(files, name)
+		}
+	}
+}
+
+// readELFBuildInfo returns the build info of an ELF Go binary, or nil when r
+// is not one
+func readELFBuildInfo(r io.Reader) (*debug.BuildInfo, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(4)
+	if err != nil || !bytes.Equal(magic, []byte("\x7fELF")) {
+		return nil, nil
+	}
+	data, err := io.ReadAll(br)
+	if err != nil {
+		return nil, err
+	}
+	info, err := buildinfo.Read(bytes.NewReader(data))
+	if err != nil {
+		// Not a Go binary, or one built without module support
+		return nil, nil
+	}
+	return info, nil
+}
+
+// walkLayer calls fn for every entry of a layer tarball, gzip-compressed or
+// not, with the entry's absolute, cleaned path
+func walkLayer(src imageSource, layer string, fn func(hdr *tar.Header, name string, r io.Reader) error) error {
+	rc, err := src.Open(layer)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	br := bufio.NewReader(rc)
+	var r io.Reader = br
+	magic, _ := br.Peek(4)
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	case bytes.Equal(magic, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		return fmt.Errorf("zstd-compressed layers are not supported")
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(hdr, path.Clean("/"+hdr.Name), tr); err != nil {
+			return err
+		}
+	}
+}
+
+// imageSource gives access to the files of an image layout, either a
+// directory or a tarball of one
+type imageSource interface {
+	Open(name string) (io.ReadCloser, error)
+	Close() error
+}
+
+func openImageSource(image string) (imageSource, error) {
+	st, err := os.Stat(image)
+	if err != nil {
+		return nil, err
+	}
+	if st.IsDir() {
+		return dirImageSource(image), nil
+	}
+	return openTarImageSource(image)
+}
+
+func readImageFile(src imageSource, name string) ([]byte, error) {
+	rc, err := src.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+type dirImageSource string
+
+func (d dirImageSource) Open(name string) (io.ReadCloser, error) {
+	// Names come from the image's manifests and must stay inside it
+	if !fs.ValidPath(name) {
+		return nil, fmt.Errorf("invalid path %q in image", name)
+	}
+	return os.Open(filepath.Join(string(d), filepath.FromSlash(name)))
+}
+
+func (d dirImageSource) Close() error { return nil }
+
+// tarImageSource indexes a tarball once so its members can be read in any
+// order without extracting it
+type tarImageSource struct {
+	file    *os.File
+	entries map[string]*io.SectionReader
+}
+
+func openTarImageSource(image string) (*tarImageSource, error) {
+	file, err := os.Open(image)
+	if err != nil {
+		return nil, err
+	}
+	src := &tarImageSource{file: file, entries: make(map[string]*io.SectionReader)}
+
+	// tar.Reader reads exactly the bytes it needs, so the count after Next
+	// is the offset of the entry's data
+	counter := &countingReader{r: file}
+	tr := tar.NewReader(counter)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to read %s: %w", image, err)
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			name := strings.TrimPrefix(path.Clean("/"+hdr.Name), "/")
+			src.entries[name] = io.NewSectionReader(file, counter.n, hdr.Size)
+		}
+	}
+	return src, nil
+}
+
+func (t *tarImageSource) Open(name string) (io.ReadCloser, error) {
+	entry, ok := t.entries[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", name, os.ErrNotExist)
+	}
+	return io.NopCloser(io.NewSectionReader(entry, 0, entry.Size())), nil
+}
+
+func (t *tarImageSource) Close() error {
+	return t.file.Close()
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
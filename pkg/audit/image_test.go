@@ -0,0 +1,175 @@
+package audit
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"debug/buildinfo"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type testTarEntry struct {
+	name string
+	mode int64
+	data []byte
+}
+
+func testTarball(t *testing.T, entries []testTarEntry, compress bool) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var gz *gzip.Writer
+	tw := tar.NewWriter(&buf)
+	if compress {
+		gz = gzip.NewWriter(&buf)
+		tw = tar.NewWriter(gz)
+	}
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Mode: e.mode, Size: int64(len(e.data)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(e.data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func testDigest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// testImageLayers returns two layers around a Go binary: the first adds it
+// at several paths, the second deletes some of them again
+func testImageLayers(t *testing.T) [][]byte {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Skip(err)
+	}
+	if _, err := buildinfo.ReadFile(exe); err != nil {
+		t.Skip("test binary has no build info:", err)
+	}
+	bin, err := os.ReadFile(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(bin, []byte("\x7fELF")) {
+		t.Skip("test binary is not an ELF file")
+	}
+
+	return [][]byte{
+		testTarball(t, []testTarEntry{
+			{"app/server", 0o755, bin},
+			{"usr/bin/gone", 0o755, bin},
+			{"usr/lib/old/tool", 0o755, bin},
+			{"opt/data.bin", 0o644, bin},
+			{"bin/script", 0o755, []byte("#!/bin/sh\n")},
+		}, true),
+		testTarball(t, []testTarEntry{
+			{"usr/bin/.wh.gone", 0o644, nil},
+			{"usr/lib/old/.wh..wh..opq", 0o644, nil},
+		}, false),
+	}
+}
+
+func TestReadImageBinariesOCILayout(t *testing.T) {
+	layers := testImageLayers(t)
+	dir := t.TempDir()
+	writeBlob := func(data []byte) string {
+		digest := testDigest(data)
+		p := filepath.Join(dir, "blobs", "sha256", digest[len("sha256:"):])
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return digest
+	}
+	mustJSON := func(v interface{}) []byte {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	config := writeBlob([]byte(`{"os":"linux","architecture":"arm64","variant":"v8"}`))
+	manifest := writeBlob(mustJSON(map[string]interface{}{
+		"mediaType": "application/vnd.oci.image.manifest.v1+json",
+		"config":    map[string]string{"mediaType": "application/vnd.oci.image.config.v1+json", "digest": config},
+		"layers": []map[string]string{
+			{"mediaType": "application/vnd.oci.image.layer.v1.tar+gzip", "digest": writeBlob(layers[0])},
+			{"mediaType": "application/vnd.oci.image.layer.v1.tar", "digest": writeBlob(layers[1])},
+		},
+	}))
+	// A nested index, as written by multi-platform builds
+	nested := writeBlob(mustJSON(map[string]interface{}{
+		"mediaType": "application/vnd.oci.image.index.v1+json",
+		"manifests": []map[string]interface{}{
+			{"mediaType": "application/vnd.oci.image.manifest.v1+json", "digest": manifest},
+			{"mediaType": "application/vnd.oci.image.manifest.v1+json", "digest": "sha256:missing", "platform": map[string]string{"os": "unknown", "architecture": "unknown"}},
+		},
+	}))
+	index := mustJSON(map[string]interface{}{
+		"schemaVersion": 2,
+		"manifests":     []map[string]string{{"mediaType": "application/vnd.oci.image.index.v1+json", "digest": nested}},
+	})
+	if err := os.WriteFile(filepath.Join(dir, "index.json"), index, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	binaries, err := ReadImageBinaries(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(binaries) != 1 || binaries[0].Path != "/app/server" || binaries[0].Platform != "linux/arm64/v8" {
+		t.Fatalf("ReadImageBinaries() = %+v", binaries)
+	}
+	if binaries[0].Info == nil || binaries[0].Info.GoVersion == "" {
+		t.Errorf("missing build info: %+v", binaries[0].Info)
+	}
+}
+
+func TestReadImageBinariesDockerSave(t *testing.T) {
+	layers := testImageLayers(t)
+	archive := testTarball(t, []testTarEntry{
+		{"manifest.json", 0o644, []byte(`[{"Config":"cfg.json","RepoTags":["app:latest"],"Layers":["l1/layer.tar","l2/layer.tar"]}]`)},
+		{"cfg.json", 0o644, []byte(`{"os":"linux","architecture":"amd64"}`)},
+		{"l1/layer.tar", 0o644, layers[0]},
+		{"./l2/layer.tar", 0o644, layers[1]},
+	}, false)
+	path := filepath.Join(t.TempDir(), "image.tar")
+	if err := os.WriteFile(path, archive, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	binaries, err := ReadImageBinaries(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(binaries) != 1 || binaries[0].Path != "/app/server" || binaries[0].Platform != "linux/amd64" {
+		t.Fatalf("ReadImageBinaries() = %+v", binaries)
+	}
+}
+
+func TestReadImageBinariesInvalid(t *testing.T) {
+	if _, err := ReadImageBinaries(t.TempDir()); err == nil {
+		t.Error("expected an error for a directory without an image layout")
+	}
+}
@@ -0,0 +1,43 @@
+package audit
+
+import (
+	"math"
+	"time"
+)
+
+// LibyearSummary totals the libyears of a project's dependencies
+type LibyearSummary struct {
+	Total    float64 `json:"total"`
+	Direct   float64 `json:"direct"`
+	Indirect float64 `json:"indirect"`
+}
+
+// SummarizeLibyears adds up the libyears of the audited modules
+func SummarizeLibyears(results []ModuleHealth) LibyearSummary {
+	var s LibyearSummary
+	for _, res := range results {
+		if res.DirectDep {
+			s.Direct += res.Libyear
+		} else {
+			s.Indirect += res.Libyear
+		}
+	}
+	s.Direct = roundLibyears(s.Direct)
+	s.Indirect = roundLibyears(s.Indirect)
+	s.Total = roundLibyears(s.Direct + s.Indirect)
+	return s
+}
+
+// libyears is the time from the used release to the latest one, in years.
+// A latest version released before the used one (e.g. a backport) counts
+// as zero drift.
+func libyears(used, latest time.Time) float64 {
+	if used.IsZero() || latest.IsZero() || !latest.After(used) {
+		return 0
+	}
+	return roundLibyears(latest.Sub(used).Hours() / 24 / 365.25)
+}
+
+func roundLibyears(y float64) float64 {
+	return math.Round(y*100) / 100
+}
@@ -0,0 +1,39 @@
+package audit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLibyears(t *testing.T) {
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		used, latest time.Time
+		want         float64
+	}{
+		{base, base.AddDate(2, 0, 0), 2},
+		{base, base.AddDate(0, 6, 0), 0.5},
+		{base, base, 0},
+		{base.AddDate(1, 0, 0), base, 0},
+		{time.Time{}, base, 0},
+	}
+	for _, tt := range tests {
+		if got := libyears(tt.used, tt.latest); got != tt.want {
+			t.Errorf("libyears(%v, %v) = %v, want %v", tt.used, tt.latest, got, tt.want)
+		}
+	}
+}
+
+func TestSummarizeLibyears(t *testing.T) {
+	results := []ModuleHealth{
+		{Path: "a", DirectDep: true, Libyear: 1.25},
+		{Path: "b", DirectDep: true, Libyear: 0.5},
+		{Path: "c", Libyear: 3.1},
+		{Path: "d"},
+	}
+	want := LibyearSummary{Total: 4.85, Direct: 1.75, Indirect: 3.1}
+	if got := SummarizeLibyears(results); got != want {
+		t.Errorf("SummarizeLibyears() = %+v, want %+v", got, want)
+	}
+}
@@ -1,7 +1,11 @@
 package audit
 
 import (
+	"archive/zip"
 	"context"
+	"io/fs"
+	"os"
+	"sort"
 	"strings"
 )
 
@@ -24,14 +28,117 @@ func DetectLicense(ctx context.Context, modulePath, version string) (string, err
 	return "Unknown", nil
 }
 
+// licenseFileNames are the file names checked in a module's root, in order.
+// These are the files 'go mod vendor' copies alongside vendored packages.
+var licenseFileNames = []string{"LICENSE", "LICENSE.md", "LICENSE.txt", "LICENCE", "LICENCE.md", "LICENCE.txt", "COPYING", "COPYING.md", "COPYING.txt"}
+
+// DetectLicenseInDir identifies the license of a module whose sources are on
+// disk, e.g. in vendor/ or the module cache
+func DetectLicenseInDir(dir string) (string, error) {
+	return detectLicenseInFS(os.DirFS(dir))
+}
+
+// DetectLicenseInZip identifies the license of a module from its zip, as
+// stored in the module cache's cache/download or served by a proxy. Files
+// in the zip are below path@version/.
+func DetectLicenseInZip(zipPath, modulePath, version string) (string, error) {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return "Unknown", err
+	}
+	defer r.Close()
+	root, err := fs.Sub(r, modulePath+"@"+version)
+	if err != nil {
+		return "Unknown", err
+	}
+	return detectLicenseInFS(root)
+}
+
+// detectLicenseInFS looks for a license file in the root of fsys
+func detectLicenseInFS(fsys fs.FS) (string, error) {
+	for _, name := range licenseFileNames {
+		data, err := fs.ReadFile(fsys, name)
+		if err == nil {
+			return IdentifyLicense(string(data)), nil
+		}
+	}
+
+	// Fall back to a case-insensitive match, e.g. "License" or "LICENSE-MIT"
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return "Unknown", err
+	}
+	var candidates []string
+	for _, e := range entries {
+		name := strings.ToUpper(e.Name())
+		if !e.IsDir() && (strings.HasPrefix(name, "LICENSE") || strings.HasPrefix(name, "LICENCE") || strings.HasPrefix(name, "COPYING")) {
+			candidates = append(candidates, e.Name())
+		}
+	}
+	sort.Strings(candidates)
+	for _, name := range candidates {
+		data, err := fs.ReadFile(fsys, name)
+		if err == nil {
+			return IdentifyLicense(string(data)), nil
+		}
+	}
+	return "Unknown", nil
+}
+
+// licenseMarkers maps distinctive phrases of license texts to SPDX
+// identifiers. More specific licenses come first: the LGPL and AGPL texts
+// mention the GPL, and the BSD-3-Clause text contains the BSD-2-Clause one.
+var licenseMarkers = []struct {
+	spdx    string
+	phrases []string
+}{
+	{"AGPL-3.0", []string{"gnu affero general public license"}},
+	{"LGPL-2.1", []string{"gnu lesser general public license", "version 2.1"}},
+	{"LGPL-3.0", []string{"gnu lesser general public license"}},
+	{"GPL-3.0", []string{"gnu general public license", "version 3"}},
+	{"GPL-2.0", []string{"gnu general public license", "version 2"}},
+	{"MPL-2.0", []string{"mozilla public license", "2.0"}},
+	{"EPL-2.0", []string{"eclipse public license", "2.0"}},
+	{"Apache-2.0", []string{"apache license", "version 2.0"}},
+	{"MIT", []string{"permission is hereby granted, free of charge"}},
+	{"ISC", []string{"permission to use, copy, modify, and/or distribute this software for any purpose"}},
+	{"BSD-3-Clause", []string{"redistribution and use in source and binary forms", "neither the name"}},
+	{"BSD-3-Clause", []string{"redistribution and use in source and binary forms", "the names of its contributors may not be used"}},
+	{"BSD-2-Clause", []string{"redistribution and use in source and binary forms"}},
+	{"Unlicense", []string{"this is free and unencumbered software released into the public domain"}},
+	{"CC0-1.0", []string{"cc0 1.0 universal"}},
+	{"BSL-1.0", []string{"boost software license"}},
+	{"Zlib", []string{"this software is provided 'as-is', without any express or implied warranty"}},
+}
+
+// IdentifyLicense returns the SPDX identifier of a license text, or "Unknown"
+func IdentifyLicense(text string) string {
+	normalized := strings.ToLower(strings.Join(strings.Fields(text), " "))
+	for _, m := range licenseMarkers {
+		matched := true
+		for _, p := range m.phrases {
+			if !strings.Contains(normalized, p) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return m.spdx
+		}
+	}
+	return "Unknown"
+}
+
 // ClassifyLicense categorizes a license string into risk levels
 func ClassifyLicense(license string) LicenseRisk {
 	l := strings.ToLower(license)
 	
 	switch {
-	case strings.Contains(l, "mit"), strings.Contains(l, "apache"), strings.Contains(l, "bsd"), strings.Contains(l, "isc"):
+	case strings.Contains(l, "mit"), strings.Contains(l, "apache"), strings.Contains(l, "bsd"), strings.Contains(l, "isc"),
+		strings.Contains(l, "unlicense"), strings.Contains(l, "cc0"), strings.Contains(l, "bsl"), strings.Contains(l, "zlib"):
 		return LicensePermissive
-	case strings.Contains(l, "gpl"), strings.Contains(l, "agpl"), strings.Contains(l, "mozilla"):
+	case strings.Contains(l, "gpl"), strings.Contains(l, "agpl"), strings.Contains(l, "mozilla"),
+		strings.Contains(l, "mpl"), strings.Contains(l, "epl"):
 		return LicenseCopyleft
 	case strings.Contains(l, "proprietary"), strings.Contains(l, "commercial"):
 		return LicenseRestrictive
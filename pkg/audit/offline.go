@@ -0,0 +1,83 @@
+package audit
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// errOffline is returned for any request made in offline mode
+var errOffline = errors.New("network access disabled in offline mode")
+
+// offlineTransport fails every request, so nothing reaches the network in
+// offline mode however a request is made
+type offlineTransport struct{}
+
+func (offlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, fmt.Errorf("%s %s: %w", req.Method, redactURL(req.URL.String()), errOffline)
+}
+
+// offlineProxies keeps the file:// entries of a GOPROXY list and adds the
+// module cache. Every entry falls back to the next one, as a directory
+// proxy rarely has everything.
+func offlineProxies(proxies []proxyEntry) []proxyEntry {
+	var offline []proxyEntry
+	for _, p := range proxies {
+		if strings.HasPrefix(p.URL, "file://") {
+			offline = append(offline, proxyEntry{URL: p.URL, AnyError: true})
+		}
+	}
+	return append(offline, proxyEntry{URL: "direct"})
+}
+
+// offlineGoEnv keeps go commands from downloading modules, so they only
+// succeed when the module cache has everything they need
+func offlineGoEnv(env []string) []string {
+	return append(env, "GOPROXY=off")
+}
+
+// goCommandEnvFor is goCommandEnv for an audit's config
+func goCommandEnvFor(config AuditConfig) []string {
+	env := goCommandEnv(config.ProjectPath)
+	if config.Offline {
+		env = offlineGoEnv(env)
+	}
+	return env
+}
+
+// moduleZip returns the path of a module version's zip on disk: in the
+// module cache, or in a file:// proxy from the GOPROXY list. It returns ""
+// when there is none; zips are never downloaded.
+func (f *Fetcher) moduleZip(modulePath, version string) string {
+	if version == "" {
+		return ""
+	}
+	var candidates []string
+	if dir := goModCacheDir(); dir != "" {
+		if p, err := modCachePath(dir, modulePath, version, "zip"); err == nil {
+			candidates = append(candidates, p)
+		}
+	}
+	for _, p := range f.proxies {
+		if !strings.HasPrefix(p.URL, "file://") {
+			continue
+		}
+		u, err := url.Parse(p.URL)
+		if err != nil {
+			continue
+		}
+		if p, err := proxyDirPath(filepath.FromSlash(u.Path), modulePath, version, "zip"); err == nil {
+			candidates = append(candidates, p)
+		}
+	}
+	for _, p := range candidates {
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return ""
+}
@@ -0,0 +1,134 @@
+package audit
+
+import (
+	"archive/zip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFiles creates files below dir from slash-separated names
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestOfflineFetcher(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer srv.Close()
+
+	modCache := t.TempDir()
+	t.Setenv("GOMODCACHE", modCache)
+	writeFiles(t, filepath.Join(modCache, "cache", "download"), map[string]string{
+		"github.com/acme/tool/@v/list":        "v1.0.0\n",
+		"github.com/acme/tool/@v/v1.0.0.info": `{"Version":"v1.0.0","Time":"2024-01-01T00:00:00Z"}`,
+		"github.com/acme/tool/@v/v1.0.0.mod":  "module github.com/acme/tool\n",
+	})
+	zipPath := filepath.Join(modCache, "cache", "download", "github.com", "acme", "tool", "@v", "v1.0.0.zip")
+	f, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	w, _ := zw.Create("github.com/acme/tool@v1.0.0/LICENSE")
+	w.Write([]byte("Permission is hereby granted, free of charge, to any person"))
+	zw.Close()
+	f.Close()
+
+	mirror := t.TempDir()
+	writeFiles(t, mirror, map[string]string{
+		"example.com/mirrored/@v/list":        "v1.0.0\nv1.1.0\n",
+		"example.com/mirrored/@v/v1.1.0.info": `{"Version":"v1.1.0","Time":"2024-06-01T00:00:00Z"}`,
+		"example.com/mirrored/@v/v1.0.0.info": `{"Version":"v1.0.0","Time":"2024-01-01T00:00:00Z"}`,
+	})
+
+	cacheDir := t.TempDir()
+	fetcher := NewFetcher(AuditConfig{
+		Offline:           true,
+		GoProxy:           srv.URL + ",file://" + filepath.ToSlash(mirror),
+		CacheDir:          cacheDir,
+		FetchRepoMetadata: true,
+	})
+	ctx := context.Background()
+
+	// The module cache has the release date, but not every version
+	meta, err := fetcher.FetchModuleMetadata(ctx, "github.com/acme/tool", "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.LastCommitDate.IsZero() || !meta.IsUnknown(SignalReleaseHistory) || !meta.IsUnknown(SignalRepository) {
+		t.Errorf("module cache metadata = %+v", meta)
+	}
+
+	// A directory proxy is a complete mirror
+	meta, err = fetcher.FetchModuleMetadata(ctx, "example.com/mirrored", "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.VersionCount != 2 || meta.IsUnknown(SignalReleaseHistory) || meta.Sources["releases_last_year"] != "builtin" {
+		t.Errorf("mirrored metadata = %+v", meta)
+	}
+
+	if _, err := fetcher.FetchModuleMetadata(ctx, "example.com/missing", "v1.0.0"); err == nil {
+		t.Error("expected an error for a module that is not on disk")
+	}
+
+	if requests != 0 {
+		t.Errorf("offline fetcher made %d requests", requests)
+	}
+	if stats, err := NewDiskCache(cacheDir, 0).Stats(); err != nil || stats.Entries != 0 {
+		t.Errorf("offline fetcher wrote cache entries: %+v, %v", stats, err)
+	}
+
+	found := fetcher.moduleZip("github.com/acme/tool", "v1.0.0")
+	if found != zipPath {
+		t.Fatalf("moduleZip() = %q, want %q", found, zipPath)
+	}
+	if license, err := DetectLicenseInZip(found, "github.com/acme/tool", "v1.0.0"); err != nil || license != "MIT" {
+		t.Errorf("DetectLicenseInZip() = %q, %v, want MIT", license, err)
+	}
+}
+
+func TestOfflineProxies(t *testing.T) {
+	proxies, err := parseGoProxy("https://proxy.golang.org,file:///srv/mirror|https://athens.example.com,direct")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []proxyEntry{{URL: "file:///srv/mirror", AnyError: true}, {URL: "direct"}}
+	got := offlineProxies(proxies)
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("offlineProxies() = %+v, want %+v", got, want)
+	}
+}
+
+func TestModCacheVersionListOnline(t *testing.T) {
+	modCache := t.TempDir()
+	t.Setenv("GOMODCACHE", modCache)
+	writeFiles(t, filepath.Join(modCache, "cache", "download"), map[string]string{
+		"git.corp.example.com/lib/@v/list": "v1.0.0\nv1.2.0\n",
+	})
+	ctx := context.Background()
+
+	// Direct modules have nothing better than the module cache
+	versions, err := NewFetcher(AuditConfig{GoProxy: "direct"}).fetchVersionList(ctx, "git.corp.example.com/lib")
+	if err != nil || len(versions) != 2 {
+		t.Errorf("fetchVersionList() = %v, %v", versions, err)
+	}
+	if _, err := NewFetcher(AuditConfig{GoProxy: "direct", Offline: true}).fetchVersionList(ctx, "git.corp.example.com/lib"); err == nil {
+		t.Error("offline, the module cache version list must not be trusted")
+	}
+}
@@ -0,0 +1,276 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// VersionStatus tells how far a module version is behind
+type VersionStatus struct {
+	// Latest is what @latest resolves to for the module path
+	Latest string
+	// LatestMinor is the highest release with the same major version,
+	// LatestPatch the highest with the same major and minor
+	LatestMinor string
+	LatestPatch string
+	// PatchesBehind counts the newer releases of the same minor version,
+	// MinorsBehind the newer minor versions of the same major
+	PatchesBehind int
+	MinorsBehind  int
+	// NewerMajor is path@version of the newest higher major version, e.g.
+	// example.com/mod/v3@v3.1.0, or "" when there is none
+	NewerMajor string
+	// Libyear is the time between the release of version and of Latest
+	Libyear float64
+	// Retracted is set when the latest go.mod retracts version, with the
+	// rationale the author gave, if any
+	Retracted           bool
+	RetractionRationale string
+}
+
+// FetchVersionStatus compares version against the versions published for
+// the module, including newer major versions under /vN module paths
+func (f *Fetcher) FetchVersionStatus(ctx context.Context, modulePath, version string) (*VersionStatus, error) {
+	versions, err := f.fetchVersionList(ctx, modulePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch version list: %w", err)
+	}
+	// Retracted versions do not count as newer releases
+	mod, _ := f.fetchLatestModFile(ctx, modulePath)
+	status := compareToReleases(version, unretracted(versions, mod))
+	status.Latest, _ = f.latestVersion(ctx, modulePath, versions)
+	if mod != nil {
+		if r, ok := mod.IsRetracted(version); ok {
+			status.Retracted = true
+			status.RetractionRationale = r.Rationale
+		}
+	}
+
+	if status.Latest != "" && status.Latest != version {
+		used, err1 := f.fetchProxyInfo(ctx, modulePath, version)
+		latest, err2 := f.fetchProxyInfo(ctx, modulePath, status.Latest)
+		if err1 == nil && err2 == nil {
+			status.Libyear = libyears(used.Time, latest.Time)
+		}
+	}
+
+	// v2+ versions tagged without a /vN path show up as +incompatible
+	// under the same path. Newer majors are probed past them even when
+	// @latest ignores them because the module has a go.mod by now.
+	prefix, major, dotted := splitPathMajor(modulePath)
+	for _, v := range versions {
+		sv, ok := parseSemver(v)
+		if !ok || !strings.HasSuffix(v, "+incompatible") {
+			continue
+		}
+		if n, err := strconv.Atoi(sv.major); err == nil && n > major {
+			major = n
+		}
+	}
+	if cur, ok := parseSemver(version); ok {
+		if latest, ok := parseSemver(status.Latest); ok && compareNumeric(latest.major, cur.major) > 0 {
+			status.NewerMajor = ModuleVersion{Path: modulePath, Version: status.Latest}.String()
+		}
+	}
+
+	// Newer majors live under their own module paths. Like the go command,
+	// stop probing at the first major version that does not exist.
+	next := major + 1
+	if !dotted && next < 2 {
+		next = 2
+	}
+	for i := 0; i < 10; i++ {
+		path := joinPathMajor(prefix, next, dotted)
+		latest, err := f.latestVersion(ctx, path, nil)
+		if err != nil || latest == "" {
+			break
+		}
+		status.NewerMajor = ModuleVersion{Path: path, Version: latest}.String()
+		next++
+	}
+
+	return &status, nil
+}
+
+// latestVersion resolves what 'go get path@latest' would: the highest
+// release, else the highest prerelease, else the proxy's @latest for modules
+// without tags. Like the go command, it skips retracted versions and
+// +incompatible ones once the module has a go.mod. versions is the module's
+// version list, if already fetched.
+func (f *Fetcher) latestVersion(ctx context.Context, modulePath string, versions []string) (string, error) {
+	candidates, mod, err := f.latestCandidates(ctx, modulePath, versions)
+	if err != nil {
+		return "", err
+	}
+	if latest := highestVersion(unretracted(candidates, mod)); latest != "" {
+		return latest, nil
+	}
+	// Everything is retracted, the go command still settles on the highest
+	if latest := highestVersion(candidates); latest != "" {
+		return latest, nil
+	}
+
+	latest, err := f.fetchProxyInfoFile(ctx, modulePath, "@latest")
+	if err != nil {
+		return "", err
+	}
+	return latest.Version, nil
+}
+
+// fetchLatestModFile parses the go.mod of the latest version, which holds
+// the module's retractions and deprecation notice
+func (f *Fetcher) fetchLatestModFile(ctx context.Context, modulePath string) (*GoModFile, error) {
+	_, mod, err := f.latestCandidates(ctx, modulePath, nil)
+	if err != nil {
+		return nil, err
+	}
+	if mod != nil {
+		return mod, nil
+	}
+	// Modules without tags only have pseudo-versions
+	latest, err := f.fetchProxyInfoFile(ctx, modulePath, "@latest")
+	if err != nil {
+		return nil, err
+	}
+	return f.fetchParsedModFile(ctx, modulePath, latest.Version)
+}
+
+// latestCandidates returns the versions @latest chooses from and the go.mod
+// of the highest of them, retractions included, or nil if it is unavailable
+func (f *Fetcher) latestCandidates(ctx context.Context, modulePath string, versions []string) ([]string, *GoModFile, error) {
+	if versions == nil {
+		var err error
+		if versions, err = f.fetchVersionList(ctx, modulePath); err != nil {
+			return nil, nil, err
+		}
+	}
+	highest := highestVersion(versions)
+	if highest == "" {
+		return versions, nil, nil
+	}
+
+	// v2+ tags of a module without a go.mod are +incompatible. Once the
+	// highest compatible version has a real go.mod, they are ignored.
+	if strings.HasSuffix(highest, "+incompatible") {
+		var compatible []string
+		for _, v := range versions {
+			if !strings.HasSuffix(v, "+incompatible") {
+				compatible = append(compatible, v)
+			}
+		}
+		if c := highestVersion(compatible); c != "" {
+			if mod, err := f.fetchParsedModFile(ctx, modulePath, c); err == nil && !synthesizedModFile(mod) {
+				return compatible, mod, nil
+			}
+		}
+	}
+
+	mod, err := f.fetchParsedModFile(ctx, modulePath, highest)
+	if err != nil {
+		return versions, nil, nil
+	}
+	return versions, mod, nil
+}
+
+func (f *Fetcher) fetchParsedModFile(ctx context.Context, modulePath, version string) (*GoModFile, error) {
+	data, err := f.fetchModFile(ctx, modulePath, version)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGoModData(data)
+}
+
+// synthesizedModFile reports whether a go.mod was made up by the proxy for
+// a version without one, in which case it only names the module
+func synthesizedModFile(mod *GoModFile) bool {
+	return mod.Go == "" && len(mod.Require) == 0 && len(mod.Replace) == 0 &&
+		len(mod.Exclude) == 0 && len(mod.Retract) == 0 && mod.Deprecated == ""
+}
+
+// highestVersion returns the highest release in sorted versions, else the
+// highest prerelease
+func highestVersion(versions []string) string {
+	for i := len(versions) - 1; i >= 0; i-- {
+		if sv, _ := parseSemver(versions[i]); sv.prerelease == "" {
+			return versions[i]
+		}
+	}
+	if len(versions) > 0 {
+		return versions[len(versions)-1]
+	}
+	return ""
+}
+
+// unretracted drops the versions mod retracts; mod may be nil
+func unretracted(versions []string, mod *GoModFile) []string {
+	if mod == nil || len(mod.Retract) == 0 {
+		return versions
+	}
+	var kept []string
+	for _, v := range versions {
+		if _, ok := mod.IsRetracted(v); !ok {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
+
+// compareToReleases counts the releases newer than version within its major
+// version. versions must be sorted; prereleases are not counted.
+func compareToReleases(version string, versions []string) VersionStatus {
+	status := VersionStatus{}
+	cur, ok := parseSemver(version)
+	if !ok {
+		return status
+	}
+
+	minors := make(map[string]bool)
+	for _, v := range versions {
+		sv, ok := parseSemver(v)
+		if !ok || sv.prerelease != "" || sv.major != cur.major || compareVersions(v, version) <= 0 {
+			continue
+		}
+		status.LatestMinor = v
+		if sv.minor == cur.minor {
+			status.LatestPatch = v
+			status.PatchesBehind++
+		} else {
+			minors[sv.minor] = true
+		}
+	}
+	status.MinorsBehind = len(minors)
+	return status
+}
+
+// splitPathMajor splits the major version suffix off a module path:
+// example.com/mod/v3 is (example.com/mod, 3, false) and gopkg.in/yaml.v2 is
+// (gopkg.in/yaml, 2, true). Paths without a suffix have major 0.
+func splitPathMajor(path string) (prefix string, major int, dotted bool) {
+	if strings.HasPrefix(path, "gopkg.in/") {
+		i := strings.LastIndex(path, ".v")
+		if i > 0 {
+			if n, err := strconv.Atoi(strings.TrimSuffix(path[i+2:], "-unstable")); err == nil {
+				return path[:i], n, true
+			}
+		}
+		return path, 0, true
+	}
+	i := strings.LastIndex(path, "/v")
+	if i > 0 {
+		suffix := path[i+2:]
+		if n, err := strconv.Atoi(suffix); err == nil && n >= 2 && suffix[0] != '0' {
+			return path[:i], n, false
+		}
+	}
+	return path, 0, false
+}
+
+// joinPathMajor is the inverse of splitPathMajor
+func joinPathMajor(prefix string, major int, dotted bool) string {
+	if dotted {
+		return prefix + ".v" + strconv.Itoa(major)
+	}
+	return prefix + "/v" + strconv.Itoa(major)
+}
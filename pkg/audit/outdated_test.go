@@ -0,0 +1,144 @@
+package audit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCompareToReleases(t *testing.T) {
+	versions := []string{"v1.0.0", "v1.0.1", "v1.1.0", "v1.1.1", "v1.1.2", "v1.2.0-rc.1", "v1.3.0", "v1.3.1", "v2.0.0+incompatible"}
+
+	tests := []struct {
+		version string
+		want    VersionStatus
+	}{
+		{"v1.1.0", VersionStatus{LatestMinor: "v1.3.1", LatestPatch: "v1.1.2", PatchesBehind: 2, MinorsBehind: 1}},
+		{"v1.0.0", VersionStatus{LatestMinor: "v1.3.1", LatestPatch: "v1.0.1", PatchesBehind: 1, MinorsBehind: 2}},
+		{"v1.3.1", VersionStatus{}},
+		{"v1.2.0-rc.1", VersionStatus{LatestMinor: "v1.3.1", MinorsBehind: 1}},
+		{"v0.0.0-20200101000000-abcdefabcdef", VersionStatus{}},
+		{"not-a-version", VersionStatus{}},
+	}
+	for _, tt := range tests {
+		if got := compareToReleases(tt.version, versions); got != tt.want {
+			t.Errorf("compareToReleases(%s) = %+v, want %+v", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestSplitPathMajor(t *testing.T) {
+	tests := []struct {
+		path   string
+		prefix string
+		major  int
+		dotted bool
+	}{
+		{"github.com/a/b", "github.com/a/b", 0, false},
+		{"github.com/a/b/v3", "github.com/a/b", 3, false},
+		{"github.com/a/v1", "github.com/a/v1", 0, false},
+		{"github.com/a/version", "github.com/a/version", 0, false},
+		{"gopkg.in/yaml.v2", "gopkg.in/yaml", 2, true},
+		{"gopkg.in/check.v1", "gopkg.in/check", 1, true},
+	}
+	for _, tt := range tests {
+		prefix, major, dotted := splitPathMajor(tt.path)
+		if prefix != tt.prefix || major != tt.major || dotted != tt.dotted {
+			t.Errorf("splitPathMajor(%s) = %s, %d, %v", tt.path, prefix, major, dotted)
+		}
+		if tt.major > 0 {
+			if got := joinPathMajor(prefix, major, dotted); got != tt.path {
+				t.Errorf("joinPathMajor(%s, %d, %v) = %s", prefix, major, dotted, got)
+			}
+		}
+	}
+}
+
+func TestHighestUnretractedVersion(t *testing.T) {
+	mod, err := ParseGoModData([]byte(`module example.com/mod
+
+go 1.21
+
+retract (
+	v1.4.0 // Contains a backwards incompatible change.
+	[v1.5.0, v1.5.2]
+)
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	versions := []string{"v1.3.0", "v1.4.0", "v1.4.1", "v1.5.0", "v1.5.2", "v1.6.0-rc.1"}
+
+	tests := []struct {
+		name     string
+		versions []string
+		mod      *GoModFile
+		want     string
+	}{
+		{"no retractions", versions, nil, "v1.5.2"},
+		{"retracted skipped", versions, mod, "v1.4.1"},
+		{"prerelease only", []string{"v0.1.0-alpha", "v0.2.0-beta"}, nil, "v0.2.0-beta"},
+		{"empty", nil, nil, ""},
+	}
+	for _, tt := range tests {
+		if got := highestVersion(unretracted(tt.versions, tt.mod)); got != tt.want {
+			t.Errorf("%s: highest = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	status := compareToReleases("v1.3.0", unretracted(versions, mod))
+	if status.MinorsBehind != 1 || status.LatestMinor != "v1.4.1" {
+		t.Errorf("compareToReleases() = %+v, retracted versions must not count", status)
+	}
+}
+
+func TestSynthesizedModFile(t *testing.T) {
+	tests := []struct {
+		data string
+		want bool
+	}{
+		{"module github.com/mattn/go-sqlite3\n", true},
+		{"module github.com/mattn/go-sqlite3\n\ngo 1.19\n", false},
+		{"module example.com/old\n\nrequire example.com/dep v1.0.0\n", false},
+	}
+	for _, tt := range tests {
+		mod, err := ParseGoModData([]byte(tt.data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := synthesizedModFile(mod); got != tt.want {
+			t.Errorf("synthesizedModFile(%q) = %v, want %v", tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestNewerMajorPastIncompatible(t *testing.T) {
+	// Only v2 +incompatible tags exist; @latest ignores them as v1.0.0 has a
+	// go.mod, and the next major lives under /v3
+	files := map[string]string{
+		"/example.com/mod/@v/list":                    "v1.0.0\nv2.1.0+incompatible\n",
+		"/example.com/mod/@v/v1.0.0.mod":              "module example.com/mod\n\ngo 1.16\n",
+		"/example.com/mod/@v/v2.1.0+incompatible.mod": "module example.com/mod\n",
+		"/example.com/mod/v3/@v/list":                 "v3.0.0\n",
+		"/example.com/mod/v3/@v/v3.0.0.mod":           "module example.com/mod/v3\n\ngo 1.16\n",
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(data))
+	}))
+	defer srv.Close()
+
+	f := NewFetcher(AuditConfig{GoProxy: srv.URL})
+	status, err := f.FetchVersionStatus(context.Background(), "example.com/mod", "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Latest != "v1.0.0" || status.NewerMajor != "example.com/mod/v3@v3.0.0" {
+		t.Errorf("FetchVersionStatus() = %+v, want latest v1.0.0 and newer major /v3", status)
+	}
+}
@@ -1,7 +1,6 @@
 package audit
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -9,7 +8,6 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-	"strings"
 )
 
 // Module represents a Go module in the dependency graph
@@ -27,13 +25,19 @@ type Module struct {
 
 // GetModuleGraph returns the full dependency graph using 'go list -m -json all'
 func GetModuleGraph(ctx context.Context, projectPath string) ([]Module, error) {
-	// Check if go.mod exists
-	if _, err := os.Stat(filepath.Join(projectPath, "go.mod")); os.IsNotExist(err) {
+	return getModuleGraph(ctx, projectPath, goCommandEnv(projectPath))
+}
+
+// getModuleGraph is GetModuleGraph with the go command's environment
+func getModuleGraph(ctx context.Context, projectPath string, env []string) ([]Module, error) {
+	// Check if go.mod (or go.work for a workspace) exists
+	if _, err := os.Stat(filepath.Join(projectPath, "go.mod")); os.IsNotExist(err) && FindGoWork(projectPath) == "" {
 		return nil, fmt.Errorf("go.mod not found in %s", projectPath)
 	}
 
 	cmd := exec.CommandContext(ctx, "go", "list", "-m", "-json", "all")
 	cmd.Dir = projectPath
+	cmd.Env = env
 	
 	var stdout bytes.Buffer
 	var stderr bytes.Buffer
@@ -57,63 +61,49 @@ func GetModuleGraph(ctx context.Context, projectPath string) ([]Module, error) {
 	return modules, nil
 }
 
-// ParseGoMod parses the go.mod file directly (fallback or for direct deps only)
-// Note: This is a simple parser and doesn't handle complex replace directives or transitive deps
+// ParseGoMod parses the go.mod file directly (fallback or for direct deps only).
+// The result starts with the main module and applies the file's replace and
+// exclude directives, so each requirement describes the code that actually
+// gets built. Transitive dependencies are not resolved.
 func ParseGoMod(path string) ([]Module, error) {
-	file, err := os.Open(path)
+	file, err := ParseGoModFile(path)
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
-
-	var modules []Module
-	scanner := bufio.NewScanner(file)
-	inRequire := false
 
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" || strings.HasPrefix(line, "//") {
+	modules := []Module{{
+		Path:      file.Module,
+		Main:      true,
+		GoMod:     path,
+		GoVersion: file.Go,
+	}}
+	for _, req := range file.Require {
+		// Since Go 1.16 a requirement on an excluded version is ignored
+		if file.IsExcluded(req.Path, req.Version) {
 			continue
 		}
-
-		if line == "require (" {
-			inRequire = true
-			continue
-		}
-		if line == ")" && inRequire {
-			inRequire = false
-			continue
+		mod := Module{
+			Path:     req.Path,
+			Version:  req.Version,
+			Indirect: req.Indirect,
 		}
-
-		if strings.HasPrefix(line, "require ") {
-			parts := strings.Fields(line)
-			if len(parts) >= 3 {
-				modules = append(modules, parseModuleLine(parts[1:]))
-			}
-		} else if inRequire {
-			parts := strings.Fields(line)
-			if len(parts) >= 2 {
-				modules = append(modules, parseModuleLine(parts))
+		if r, ok := file.Replacement(req.Path, req.Version); ok {
+			mod.Replace = &Module{Path: r.Path, Version: r.Version}
+			if r.Version == "" {
+				mod.Replace.Dir = resolveLocalPath(filepath.Dir(path), r.Path)
 			}
 		}
+		modules = append(modules, mod)
 	}
 
-	return modules, scanner.Err()
+	return modules, nil
 }
 
-func parseModuleLine(parts []string) Module {
-	mod := Module{
-		Path:    parts[0],
-		Version: parts[1],
-	}
-	// Check for // indirect comment
-	for _, p := range parts {
-		if p == "//" {
-			continue
-		}
-		if p == "indirect" {
-			mod.Indirect = true
-		}
+// resolveLocalPath resolves a directory replacement relative to the go.mod
+// that declares it
+func resolveLocalPath(base, dir string) string {
+	if filepath.IsAbs(dir) {
+		return dir
 	}
-	return mod
+	return filepath.Join(base, filepath.FromSlash(dir))
 }
@@ -0,0 +1,273 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Reachability tells whether a module's code ends up in what we ship
+type Reachability int
+
+const (
+	// ReachabilityUnknown means no package analysis was done
+	ReachabilityUnknown Reachability = iota
+	// ReachabilityBuild modules provide packages imported by the main
+	// modules' non-test code, so they are linked into binaries
+	ReachabilityBuild
+	// ReachabilityTest modules are only imported by tests
+	ReachabilityTest
+	// ReachabilityGraph modules are in the module graph, but none of their
+	// packages is imported
+	ReachabilityGraph
+)
+
+func (r Reachability) String() string {
+	switch r {
+	case ReachabilityBuild:
+		return "Build"
+	case ReachabilityTest:
+		return "Test"
+	case ReachabilityGraph:
+		return "Graph"
+	default:
+		return "Unknown"
+	}
+}
+
+// BuildTarget is a platform and set of build tags packages are listed for
+type BuildTarget struct {
+	GOOS   string `json:"goos" yaml:"goos"`
+	GOARCH string `json:"goarch" yaml:"goarch"`
+	// Tags are extra build tags. The "cgo" tag enables cgo; without it
+	// packages are listed with CGO_ENABLED=0.
+	Tags []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+// ParseBuildTarget parses "GOOS/GOARCH" with optional ":tag1,tag2"
+func ParseBuildTarget(s string) (BuildTarget, error) {
+	platform, tags, hasTags := strings.Cut(s, ":")
+	goos, goarch, ok := strings.Cut(platform, "/")
+	if !ok || goos == "" || goarch == "" || strings.Contains(goarch, "/") {
+		return BuildTarget{}, fmt.Errorf("invalid platform %q, want GOOS/GOARCH[:tags]", s)
+	}
+	target := BuildTarget{GOOS: goos, GOARCH: goarch}
+	if hasTags {
+		for _, tag := range strings.Split(tags, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				target.Tags = append(target.Tags, tag)
+			}
+		}
+	}
+	return target, nil
+}
+
+// String renders the target the way ParseBuildTarget reads it
+func (t BuildTarget) String() string {
+	s := t.GOOS + "/" + t.GOARCH
+	if len(t.Tags) > 0 {
+		s += ":" + strings.Join(t.Tags, ",")
+	}
+	return s
+}
+
+// args returns the go list flags and environment for the target
+func (t BuildTarget) args() (flags, env []string) {
+	cgo := "0"
+	var tags []string
+	for _, tag := range t.Tags {
+		if tag == "cgo" {
+			cgo = "1"
+			continue
+		}
+		tags = append(tags, tag)
+	}
+	if len(tags) > 0 {
+		flags = []string{"-tags=" + strings.Join(tags, ",")}
+	}
+	env = []string{"GOOS=" + t.GOOS, "GOARCH=" + t.GOARCH, "CGO_ENABLED=" + cgo}
+	return flags, env
+}
+
+// ReachabilityAnalysis is the result of a package analysis
+type ReachabilityAnalysis struct {
+	// Modules has the reachability of each module providing a package;
+	// modules missing from it are graph-only
+	Modules map[string]Reachability
+	// Platforms lists, per module, the build targets it is linked into.
+	// It is only filled when the analysis ran over explicit targets.
+	Platforms map[string][]string
+}
+
+// AnalyzeReachability lists the packages of the project (every workspace
+// module for a go.work) with their dependencies, once without and once with
+// tests, and returns the reachability of each module providing a package.
+// Without targets the packages are listed for the host platform; with
+// targets a module is linked when it is linked into any of them.
+func AnalyzeReachability(ctx context.Context, projectPath string, targets []BuildTarget) (*ReachabilityAnalysis, error) {
+	return analyzeReachability(ctx, projectPath, targets, goCommandEnv(projectPath))
+}
+
+// analyzeReachability is AnalyzeReachability with the go command's
+// environment
+func analyzeReachability(ctx context.Context, projectPath string, targets []BuildTarget, env []string) (*ReachabilityAnalysis, error) {
+	patterns, err := reachabilityPatterns(projectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	analysis := &ReachabilityAnalysis{
+		Modules:   make(map[string]Reachability),
+		Platforms: make(map[string][]string),
+	}
+	host := len(targets) == 0
+	if host {
+		targets = []BuildTarget{{}}
+	}
+	for _, target := range targets {
+		build, err := listPackageModules(ctx, projectPath, env, patterns, false, target)
+		if err != nil {
+			return nil, err
+		}
+		test, err := listPackageModules(ctx, projectPath, env, patterns, true, target)
+		if err != nil {
+			return nil, err
+		}
+
+		for path := range build {
+			analysis.Modules[path] = ReachabilityBuild
+			if !host {
+				analysis.Platforms[path] = append(analysis.Platforms[path], target.String())
+			}
+		}
+		for path := range test {
+			if _, ok := analysis.Modules[path]; !ok {
+				analysis.Modules[path] = ReachabilityTest
+			}
+		}
+	}
+	return analysis, nil
+}
+
+// reachabilityPatterns returns the package patterns covering the project:
+// ./... for a module, every use directory for a workspace
+func reachabilityPatterns(projectPath string) ([]string, error) {
+	gowork := FindGoWork(projectPath)
+	if gowork == "" {
+		return []string{"./..."}, nil
+	}
+	work, err := ParseGoWorkFile(gowork)
+	if err != nil {
+		return nil, err
+	}
+	var patterns []string
+	for _, use := range work.Use {
+		dir := resolveLocalPath(filepath.Dir(gowork), use)
+		patterns = append(patterns, filepath.ToSlash(filepath.Join(dir, "...")))
+	}
+	return patterns, nil
+}
+
+// listPackageModules runs 'go list -deps -json' with env and returns the paths of
+// the dependency modules that provide at least one package when built
+// for target, or the host platform when target is zero
+func listPackageModules(ctx context.Context, projectPath string, env, patterns []string, tests bool, target BuildTarget) (map[string]bool, error) {
+	args := []string{"list", "-e", "-deps", "-json=ImportPath,Standard,Module"}
+	if tests {
+		args = append(args, "-test")
+	}
+	env = env[:len(env):len(env)]
+	if target.GOOS != "" {
+		flags, targetEnv := target.args()
+		args = append(args, flags...)
+		env = append(env, targetEnv...)
+	}
+	cmd := exec.CommandContext(ctx, "go", append(args, patterns...)...)
+	cmd.Dir = projectPath
+	cmd.Env = env
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to run go list -deps: %w, stderr: %s", err, stderr.String())
+	}
+
+	return ParsePackageModules(&stdout)
+}
+
+// ParsePackageModules reads 'go list -json' package output and returns the
+// paths of the non-main modules that provide the listed packages
+func ParsePackageModules(r io.Reader) (map[string]bool, error) {
+	modules := make(map[string]bool)
+	dec := json.NewDecoder(r)
+	for {
+		var pkg struct {
+			ImportPath string
+			Standard   bool
+			Module     *struct {
+				Path string
+				Main bool
+			}
+		}
+		if err := dec.Decode(&pkg); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse go list output: %w", err)
+		}
+		if pkg.Standard || pkg.Module == nil || pkg.Module.Main {
+			continue
+		}
+		modules[pkg.Module.Path] = true
+	}
+	return modules, nil
+}
+
+// linkedReachability marks every non-main module as built, which is what
+// a binary's build info lists. platform is the binary's, if known.
+func linkedReachability(modules []Module, platform string) *ReachabilityAnalysis {
+	analysis := &ReachabilityAnalysis{
+		Modules:   make(map[string]Reachability),
+		Platforms: make(map[string][]string),
+	}
+	for _, m := range modules {
+		if m.Main {
+			continue
+		}
+		analysis.Modules[m.Path] = ReachabilityBuild
+		if platform != "" {
+			analysis.Platforms[m.Path] = []string{platform}
+		}
+	}
+	return analysis
+}
+
+// of looks path up in the analysis. Without an analysis everything is
+// unknown; with one, modules providing no package are graph-only.
+func (a *ReachabilityAnalysis) of(path string) (Reachability, []string) {
+	if a == nil {
+		return ReachabilityUnknown, nil
+	}
+	if r, ok := a.Modules[path]; ok {
+		return r, a.Platforms[path]
+	}
+	return ReachabilityGraph, nil
+}
+
+// mergeReachability combines the reachability of a module in several
+// projects or binaries: linked anywhere means linked
+func mergeReachability(a, b Reachability) Reachability {
+	for _, r := range []Reachability{ReachabilityBuild, ReachabilityUnknown, ReachabilityTest} {
+		if a == r || b == r {
+			return r
+		}
+	}
+	return ReachabilityGraph
+}
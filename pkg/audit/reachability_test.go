@@ -0,0 +1,116 @@
+package audit
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePackageModules(t *testing.T) {
+	output := `{
+	"ImportPath": "fmt",
+	"Standard": true
+}
+{
+	"ImportPath": "github.com/spf13/pflag",
+	"Module": {"Path": "github.com/spf13/pflag", "Version": "v1.0.5"}
+}
+{
+	"ImportPath": "github.com/fork/cmp/cmp",
+	"Module": {"Path": "github.com/google/go-cmp", "Version": "v0.6.0", "Replace": {"Path": "github.com/fork/cmp", "Version": "v0.6.1"}}
+}
+{
+	"ImportPath": "example.com/app",
+	"Module": {"Path": "example.com/app", "Main": true}
+}
+{
+	"ImportPath": "example.com/app.test"
+}
+`
+	modules, err := ParsePackageModules(strings.NewReader(output))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(modules) != 2 || !modules["github.com/spf13/pflag"] || !modules["github.com/google/go-cmp"] {
+		t.Errorf("ParsePackageModules() = %v", modules)
+	}
+
+	if _, err := ParsePackageModules(strings.NewReader("{")); err == nil {
+		t.Error("expected an error for truncated output")
+	}
+}
+
+func TestReachabilityAnalysisOf(t *testing.T) {
+	analysis := &ReachabilityAnalysis{
+		Modules:   map[string]Reachability{"a": ReachabilityBuild, "b": ReachabilityTest},
+		Platforms: map[string][]string{"a": {"linux/amd64"}},
+	}
+	tests := []struct {
+		analysis  *ReachabilityAnalysis
+		path      string
+		want      Reachability
+		platforms int
+	}{
+		{analysis, "a", ReachabilityBuild, 1},
+		{analysis, "b", ReachabilityTest, 0},
+		{analysis, "c", ReachabilityGraph, 0},
+		{nil, "a", ReachabilityUnknown, 0},
+	}
+	for _, tt := range tests {
+		got, platforms := tt.analysis.of(tt.path)
+		if got != tt.want || len(platforms) != tt.platforms {
+			t.Errorf("of(%q) = %v, %v, want %v with %d platforms", tt.path, got, platforms, tt.want, tt.platforms)
+		}
+	}
+}
+
+func TestParseBuildTarget(t *testing.T) {
+	tests := []struct {
+		in       string
+		want     string
+		flags    string
+		cgo      string
+		wantFail bool
+	}{
+		{in: "linux/amd64", want: "linux/amd64", cgo: "CGO_ENABLED=0"},
+		{in: "windows/arm64:cgo, netgo", want: "windows/arm64:cgo,netgo", flags: "-tags=netgo", cgo: "CGO_ENABLED=1"},
+		{in: "linux", wantFail: true},
+		{in: "linux/arm/v7", wantFail: true},
+		{in: "/amd64", wantFail: true},
+	}
+	for _, tt := range tests {
+		target, err := ParseBuildTarget(tt.in)
+		if tt.wantFail {
+			if err == nil {
+				t.Errorf("ParseBuildTarget(%q) succeeded, want error", tt.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseBuildTarget(%q): %v", tt.in, err)
+			continue
+		}
+		if target.String() != tt.want {
+			t.Errorf("ParseBuildTarget(%q) = %s, want %s", tt.in, target, tt.want)
+		}
+		flags, env := target.args()
+		if strings.Join(flags, " ") != tt.flags || env[len(env)-1] != tt.cgo {
+			t.Errorf("%s: flags %v, env %v", tt.in, flags, env)
+		}
+	}
+}
+
+func TestMergeReachability(t *testing.T) {
+	tests := []struct {
+		a, b, want Reachability
+	}{
+		{ReachabilityGraph, ReachabilityBuild, ReachabilityBuild},
+		{ReachabilityTest, ReachabilityGraph, ReachabilityTest},
+		{ReachabilityUnknown, ReachabilityTest, ReachabilityUnknown},
+		{ReachabilityGraph, ReachabilityGraph, ReachabilityGraph},
+	}
+	for _, tt := range tests {
+		if got := mergeReachability(tt.a, tt.b); got != tt.want {
+			t.Errorf("mergeReachability(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
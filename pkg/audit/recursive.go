@@ -0,0 +1,157 @@
+package audit
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ProjectAudit holds the results for one module of a recursive audit
+type ProjectAudit struct {
+	ProjectPath string         `json:"project_path"`
+	Module      string         `json:"module"`
+	Results     []ModuleHealth `json:"results"`
+	Error       string         `json:"error,omitempty"`
+}
+
+// RecursiveAudit is the result of auditing every module below a directory
+type RecursiveAudit struct {
+	Root     string         `json:"root"`
+	Projects []ProjectAudit `json:"projects"`
+	// Rollup lists every dependency version once; RequiredBy names the
+	// audited modules that use it
+	Rollup []ModuleHealth `json:"rollup"`
+}
+
+// DiscoverModules returns the directories below root that contain a go.mod,
+// sorted. Like the go command it skips vendor and testdata directories and
+// directories starting with "." or "_".
+func DiscoverModules(root string) ([]string, error) {
+	var dirs []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != root && (name == "vendor" || name == "testdata" ||
+				strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Name() == "go.mod" {
+			dirs = append(dirs, filepath.Dir(path))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+// AuditRecursive audits every module found below config.ProjectPath. All
+// projects share one Fetcher, so a dependency used by many modules is only
+// fetched once. A project that fails to audit is reported with its error and
+// does not stop the others.
+func AuditRecursive(ctx context.Context, config AuditConfig) (*RecursiveAudit, error) {
+	dirs, err := DiscoverModules(config.ProjectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	fetcher := auditFetcher(config)
+	result := &RecursiveAudit{Root: config.ProjectPath}
+	for _, dir := range dirs {
+		project := ProjectAudit{ProjectPath: dir}
+		if f, err := ParseGoModFile(filepath.Join(dir, "go.mod")); err == nil {
+			project.Module = f.Module
+		}
+
+		projectConfig := config
+		projectConfig.ProjectPath = dir
+		results, err := auditProject(ctx, projectConfig, fetcher)
+		if err != nil {
+			project.Error = err.Error()
+		}
+		project.Results = results
+		result.Projects = append(result.Projects, project)
+	}
+
+	result.Rollup = rollupResults(result.Projects)
+	return result, nil
+}
+
+// rollupResults merges project results into one entry per dependency
+// version, attributed to the projects that use it
+func rollupResults(projects []ProjectAudit) []ModuleHealth {
+	groups := make([]resultGroup, len(projects))
+	for i, p := range projects {
+		name := p.Module
+		if name == "" {
+			name = p.ProjectPath
+		}
+		groups[i] = resultGroup{name: name, results: p.Results}
+	}
+	return rollup(groups)
+}
+
+// resultGroup is the audit result of one project or binary
+type resultGroup struct {
+	name    string
+	results []ModuleHealth
+}
+
+// rollup merges results into one entry per dependency version, with
+// RequiredBy naming the groups that use it
+func rollup(groups []resultGroup) []ModuleHealth {
+	var merged []ModuleHealth
+	index := make(map[string]int)
+	for _, g := range groups {
+		for _, res := range g.results {
+			key := res.Path + "@" + res.Version
+			i, ok := index[key]
+			if !ok {
+				index[key] = len(merged)
+				res.RequiredBy = []string{g.name}
+				merged = append(merged, res)
+				continue
+			}
+			if res.DirectDep {
+				merged[i].DirectDep = true
+			}
+			merged[i].Reachability = mergeReachability(merged[i].Reachability, res.Reachability)
+			merged[i].Platforms = mergePlatforms(merged[i].Platforms, res.Platforms)
+			merged[i].RequiredBy = append(merged[i].RequiredBy, g.name)
+		}
+	}
+
+	sort.Slice(merged, func(i, j int) bool {
+		if merged[i].Path != merged[j].Path {
+			return merged[i].Path < merged[j].Path
+		}
+		return compareVersions(merged[i].Version, merged[j].Version) < 0
+	})
+	return merged
+}
+
+// mergePlatforms returns the sorted union of two platform lists
+func mergePlatforms(a, b []string) []string {
+	if len(b) == 0 {
+		return a
+	}
+	seen := make(map[string]bool)
+	var merged []string
+	for _, p := range append(append([]string{}, a...), b...) {
+		if !seen[p] {
+			seen[p] = true
+			merged = append(merged, p)
+		}
+	}
+	sort.Strings(merged)
+	return merged
+}
@@ -0,0 +1,63 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDiscoverModules(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"", "svc/api", "svc/api/internal/tool", "libs/x", "vendor/example.com/v", "libs/x/testdata/m", ".git/m", "_old"} {
+		p := filepath.Join(root, dir)
+		if err := os.MkdirAll(p, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(p, "go.mod"), []byte("module example.com/m\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := DiscoverModules(root)
+	if err != nil {
+		t.Fatalf("DiscoverModules() error = %v", err)
+	}
+	want := []string{
+		root,
+		filepath.Join(root, "libs/x"),
+		filepath.Join(root, "svc/api"),
+		filepath.Join(root, "svc/api/internal/tool"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiscoverModules() = %v, want %v", got, want)
+	}
+}
+
+func TestRollupResults(t *testing.T) {
+	projects := []ProjectAudit{
+		{Module: "example.com/a", Results: []ModuleHealth{
+			{Path: "example.com/shared", Version: "v1.0.0"},
+			{Path: "example.com/only-a", Version: "v0.1.0", DirectDep: true},
+		}},
+		{Module: "example.com/b", Results: []ModuleHealth{
+			{Path: "example.com/shared", Version: "v1.0.0", DirectDep: true},
+			{Path: "example.com/shared", Version: "v1.2.0"},
+		}},
+	}
+
+	rollup := rollupResults(projects)
+	if len(rollup) != 3 {
+		t.Fatalf("rollupResults() = %+v", rollup)
+	}
+	shared := rollup[1]
+	if shared.Path != "example.com/shared" || shared.Version != "v1.0.0" || !shared.DirectDep {
+		t.Errorf("unexpected shared entry %+v", shared)
+	}
+	if !reflect.DeepEqual(shared.RequiredBy, []string{"example.com/a", "example.com/b"}) {
+		t.Errorf("shared RequiredBy = %v", shared.RequiredBy)
+	}
+	if rollup[2].Version != "v1.2.0" || !reflect.DeepEqual(rollup[2].RequiredBy, []string{"example.com/b"}) {
+		t.Errorf("unexpected entry %+v", rollup[2])
+	}
+}
@@ -0,0 +1,228 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// RepoMetadata is what a code forge reports about a repository
+type RepoMetadata struct {
+	URL          string
+	Stars        int
+	Forks        int
+	OpenIssues   int
+	Contributors int
+	// RecentCommits counts the commits on the default branch in the last
+	// commitWindowDays days
+	RecentCommits int
+	LastPush      time.Time
+	// Archived repositories are read-only and no longer maintained
+	Archived bool
+	// Unknown lists what the forge could not report: SignalStars,
+	// SignalContributors or SignalCommits
+	Unknown []string
+}
+
+// commitWindowDays is the period commit frequency is measured over
+const commitWindowDays = 90
+
+// apply copies the repository statistics into the module metadata
+func (r *RepoMetadata) apply(meta *ModuleMetadata) {
+	if r.URL != "" {
+		meta.RepositoryURL = r.URL
+	}
+	meta.Stars = r.Stars
+	meta.Forks = r.Forks
+	meta.OpenIssues = r.OpenIssues
+	meta.Contributors = r.Contributors
+	meta.Archived = r.Archived
+	months := float64(commitWindowDays) / 30
+	meta.CommitFrequency = math.Round(float64(r.RecentCommits)/months*10) / 10
+	// LastCommitDate stays the release date of the version in use; a push
+	// to any branch says nothing about how old that version is
+	meta.LastPush = r.LastPush
+	meta.Unknown = append(meta.Unknown, r.Unknown...)
+}
+
+// RepoProvider fetches repository metadata from one code forge
+type RepoProvider interface {
+	// FetchRepo returns the statistics of the repository at repoPath,
+	// e.g. "owner/name" or "group/subgroup/name" on GitLab
+	FetchRepo(ctx context.Context, repoPath string) (*RepoMetadata, error)
+}
+
+// Forge types for ForgeConfig.Type
+const (
+	ForgeGitHub    = "github"
+	ForgeGitLab    = "gitlab"
+	ForgeGitea     = "gitea" // also Forgejo
+	ForgeBitbucket = "bitbucket"
+)
+
+// ForgeConfig registers a code forge host, typically a self-hosted instance
+type ForgeConfig struct {
+	// Host is the host name in module paths, e.g. gitlab.example.com
+	Host string `json:"host" yaml:"host"`
+	// Type is github, gitlab, gitea (also for Forgejo) or bitbucket
+	Type string `json:"type" yaml:"type"`
+	// BaseURL is the API root. It defaults to the instance's standard API
+	// location below https://<host>.
+	BaseURL string `json:"base_url" yaml:"base_url"`
+	Token   string `json:"token" yaml:"token"`
+}
+
+// ParseForge parses "host=type" with an optional ",baseURL", e.g.
+// "git.example.com=gitlab" or "ghe.example.com=github,https://ghe.example.com/api/v3"
+func ParseForge(s string) (ForgeConfig, error) {
+	host, rest, ok := strings.Cut(s, "=")
+	forgeType, base, _ := strings.Cut(rest, ",")
+	if !ok || host == "" || forgeType == "" {
+		return ForgeConfig{}, fmt.Errorf("invalid forge %q, want host=type[,baseURL]", s)
+	}
+	return ForgeConfig{Host: host, Type: forgeType, BaseURL: base}, nil
+}
+
+// newRepoProvider returns the provider for a forge
+func newRepoProvider(forge ForgeConfig, client *http.Client) (RepoProvider, error) {
+	base := forge.BaseURL
+	switch forge.Type {
+	case ForgeGitHub:
+		if base == "" && forge.Host != "github.com" {
+			// GitHub Enterprise Server
+			base = "https://" + forge.Host + "/api/v3"
+		}
+		return NewGitHubClient(base, forge.Token, client), nil
+	case ForgeGitLab:
+		if base == "" {
+			base = "https://" + forge.Host
+		}
+		return NewGitLabClient(base, forge.Token, client), nil
+	case ForgeGitea, "forgejo":
+		if base == "" {
+			base = "https://" + forge.Host
+		}
+		return NewGiteaClient(base, forge.Token, client), nil
+	case ForgeBitbucket:
+		return NewBitbucketClient(base, forge.Token, client), nil
+	}
+	return nil, fmt.Errorf("unknown forge type %q for %s", forge.Type, forge.Host)
+}
+
+// repoProviders returns the providers by host: the public forges, then the
+// configured ones, which can also override a public forge's settings
+func repoProviders(config AuditConfig, client *http.Client) map[string]RepoProvider {
+	forges := []ForgeConfig{
+		{Host: "github.com", Type: ForgeGitHub, BaseURL: config.GitHubBaseURL, Token: config.GitHubToken},
+		{Host: "gitlab.com", Type: ForgeGitLab, Token: config.GitLabToken},
+		{Host: "codeberg.org", Type: ForgeGitea},
+		{Host: "bitbucket.org", Type: ForgeBitbucket},
+	}
+	providers := make(map[string]RepoProvider)
+	for _, forge := range append(forges, config.Forges...) {
+		p, err := newRepoProvider(forge, client)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v, repository metadata is unavailable for it\n", err)
+			continue
+		}
+		providers[forge.Host] = p
+	}
+	return providers
+}
+
+// fetchRepoMetadata fetches repository statistics once per repository, as
+// many modules can live in the same repository
+func (f *Fetcher) fetchRepoMetadata(ctx context.Context, repoURL string) (*RepoMetadata, error) {
+	return memoize(ctx, &f.mu, f.repos, repoURL, func() (*RepoMetadata, error) {
+		u, err := url.Parse(repoURL)
+		if err != nil {
+			return nil, err
+		}
+		provider, ok := f.providers[u.Host]
+		if !ok {
+			return nil, fmt.Errorf("no metadata provider for %s", u.Host)
+		}
+
+		key := "repo/" + u.Host + u.Path
+		if cached, fresh := f.cache.get(key, false); fresh {
+			var repo RepoMetadata
+			if err := json.Unmarshal(cached.Data, &repo); err == nil {
+				return &repo, nil
+			}
+		}
+		repo, err := provider.FetchRepo(ctx, strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git"))
+		if err != nil {
+			return nil, err
+		}
+		if data, err := json.Marshal(repo); err == nil {
+			f.cache.put(key, &cacheEntry{Fetched: time.Now(), Data: data})
+		}
+		return repo, nil
+	})
+}
+
+// repoURL guesses the repository of a module hosted on a known forge: the
+// first two path elements after the host, or everything up to a ".git"
+// element for nested GitLab groups
+func (f *Fetcher) repoURL(modulePath string) string {
+	host, rest, _ := strings.Cut(modulePath, "/")
+	if _, ok := f.providers[host]; !ok {
+		return ""
+	}
+	parts := strings.Split(rest, "/")
+	for i, p := range parts {
+		if strings.HasSuffix(p, ".git") {
+			return "https://" + host + "/" + strings.Join(parts[:i], "/") + "/" + strings.TrimSuffix(p, ".git")
+		}
+	}
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return ""
+	}
+	return "https://" + host + "/" + parts[0] + "/" + parts[1]
+}
+
+// getJSON fetches u and decodes a 200 response into v. Responses with a
+// status in empty mean an empty result and leave v untouched.
+func getJSON(ctx context.Context, client *http.Client, u string, header http.Header, v interface{}, empty ...int) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", req.URL.Path, err)
+		}
+		return resp, nil
+	}
+	for _, status := range empty {
+		if resp.StatusCode == status {
+			return resp, nil
+		}
+	}
+	return nil, fmt.Errorf("%s returned status %d for %s", req.URL.Host, resp.StatusCode, req.URL.Path)
+}
+
+// splitRepoPath splits "owner/name" for forges without nested groups
+func splitRepoPath(repoPath string) (owner, name string, err error) {
+	owner, name, ok := strings.Cut(repoPath, "/")
+	if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return "", "", fmt.Errorf("invalid repository %q", repoPath)
+	}
+	return owner, name, nil
+}
@@ -0,0 +1,298 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Resolver computes the build list of a main module without the go command.
+// It loads the go.mod file of every module version in the requirement graph,
+// from GOMODCACHE when present and from the module proxy otherwise, and runs
+// Minimal Version Selection over them the way 'go list -m all' does,
+// including graph pruning for go 1.17+ modules.
+type Resolver struct {
+	fetcher *Fetcher
+
+	mu   sync.Mutex
+	mods map[ModuleVersion]*GoModFile
+}
+
+// NewResolver returns a Resolver that downloads go.mod files with fetcher
+func NewResolver(fetcher *Fetcher) *Resolver {
+	return &Resolver{
+		fetcher: fetcher,
+		mods:    make(map[ModuleVersion]*GoModFile),
+	}
+}
+
+// Resolve returns the build list of the module in projectPath, main module
+// first, and the requirement graph between the selected versions.
+func (r *Resolver) Resolve(ctx context.Context, projectPath string) ([]Module, *DependencyGraph, error) {
+	gomod := filepath.Join(projectPath, "go.mod")
+	main, err := ParseGoModFile(gomod)
+	if err != nil {
+		return nil, nil, err
+	}
+	if main.Module == "" {
+		return nil, nil, fmt.Errorf("%s: missing module directive", gomod)
+	}
+	rules := fileRules{file: main, dir: projectPath}
+	return r.resolve(ctx, []resolveRoot{{file: main, gomod: gomod}}, rules)
+}
+
+// resolveRoot is a main module taking part in version selection
+type resolveRoot struct {
+	file  *GoModFile
+	gomod string
+}
+
+// resolveRules decides which replacements and exclusions apply. Only the
+// main module's (or the workspace's) directives count, never a dependency's.
+// dir is the absolute directory of a local replacement.
+type resolveRules interface {
+	replacement(path, version string) (repl ModuleVersion, dir string, ok bool)
+	excluded(path, version string) bool
+}
+
+// fileRules applies the directives of a single go.mod in dir
+type fileRules struct {
+	file *GoModFile
+	dir  string
+}
+
+func (fr fileRules) replacement(path, version string) (ModuleVersion, string, bool) {
+	repl, ok := fr.file.Replacement(path, version)
+	if !ok {
+		return ModuleVersion{}, "", false
+	}
+	dir := ""
+	if repl.Version == "" {
+		dir = resolveLocalPath(fr.dir, repl.Path)
+	}
+	return repl, dir, true
+}
+
+func (fr fileRules) excluded(path, version string) bool {
+	return fr.file.IsExcluded(path, version)
+}
+
+func (r *Resolver) resolve(ctx context.Context, roots []resolveRoot, rules resolveRules) ([]Module, *DependencyGraph, error) {
+	// The graph is pruned when every main module is at go 1.17 or later
+	pruned := true
+	mainPaths := make(map[string]bool)
+	for _, root := range roots {
+		mainPaths[root.file.Module] = true
+		if !goVersionAtLeast(root.file.Go, "1.17") {
+			pruned = false
+		}
+	}
+
+	versions := make(map[string]map[string]bool) // every version seen per path
+	requires := make(map[ModuleVersion][]string) // loaded requirement paths
+	expanded := make(map[ModuleVersion]bool)     // go.mod loaded or queued
+	var queue []ModuleVersion
+
+	add := func(mv ModuleVersion, expand bool) {
+		if mainPaths[mv.Path] {
+			return
+		}
+		if versions[mv.Path] == nil {
+			versions[mv.Path] = make(map[string]bool)
+		}
+		versions[mv.Path][mv.Version] = true
+		if expand && !expanded[mv] {
+			expanded[mv] = true
+			queue = append(queue, mv)
+		}
+	}
+
+	directs := make(map[string]bool) // required by a main module without // indirect
+	for _, root := range roots {
+		for _, req := range root.file.Require {
+			if rules.excluded(req.Path, req.Version) {
+				continue
+			}
+			if !req.Indirect {
+				directs[req.Path] = true
+			}
+			add(ModuleVersion{Path: req.Path, Version: req.Version}, true)
+		}
+	}
+
+	for len(queue) > 0 {
+		// Load the next layer of go.mod files concurrently
+		batch := queue
+		queue = nil
+
+		files, err := r.loadAll(ctx, batch, rules)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for i, mv := range batch {
+			dep := files[i]
+			depPruned := pruned && goVersionAtLeast(dep.Go, "1.17")
+			for _, req := range dep.Require {
+				if rules.excluded(req.Path, req.Version) {
+					continue
+				}
+				requires[mv] = append(requires[mv], req.Path)
+				// A pruned module contributes its requirements, but not
+				// their requirements in turn
+				add(ModuleVersion{Path: req.Path, Version: req.Version}, !depPruned)
+			}
+		}
+	}
+
+	// Minimal Version Selection: the highest version reached wins
+	var paths []string
+	selected := make(map[string]string)
+	for path, vs := range versions {
+		for v := range vs {
+			if cur, ok := selected[path]; !ok || compareVersions(v, cur) > 0 {
+				selected[path] = v
+			}
+		}
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var rootPaths []string
+	var modules []Module
+	for _, root := range roots {
+		rootPaths = append(rootPaths, root.file.Module)
+		modules = append(modules, Module{
+			Path:      root.file.Module,
+			Main:      true,
+			Dir:       filepath.Dir(root.gomod),
+			GoMod:     root.gomod,
+			GoVersion: root.file.Go,
+		})
+	}
+
+	graph := NewDependencyGraph(rootPaths...)
+	for _, root := range roots {
+		for _, req := range root.file.Require {
+			if !rules.excluded(req.Path, req.Version) {
+				graph.AddEdge(root.file.Module, req.Path)
+			}
+		}
+	}
+
+	for _, path := range paths {
+		mv := ModuleVersion{Path: path, Version: selected[path]}
+		mod := Module{
+			Path:     path,
+			Version:  mv.Version,
+			Indirect: !directs[path],
+		}
+		if repl, dir, ok := rules.replacement(mv.Path, mv.Version); ok {
+			mod.Replace = &Module{Path: repl.Path, Version: repl.Version, Dir: dir}
+		}
+		modules = append(modules, mod)
+
+		graph.AddModule(path, mv.Version)
+		for _, child := range requires[mv] {
+			graph.AddEdge(path, child)
+		}
+	}
+
+	return modules, graph, nil
+}
+
+// loadAll loads the go.mod files of a batch of module versions in parallel
+func (r *Resolver) loadAll(ctx context.Context, batch []ModuleVersion, rules resolveRules) ([]*GoModFile, error) {
+	files := make([]*GoModFile, len(batch))
+	errs := make([]error, len(batch))
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, 10) // Limit concurrency
+	for i, mv := range batch {
+		wg.Add(1)
+		go func(i int, mv ModuleVersion) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			files[i], errs[i] = r.load(ctx, mv, rules)
+		}(i, mv)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("loading go.mod of %s: %w", batch[i], err)
+		}
+	}
+	return files, nil
+}
+
+// load returns the go.mod of mv, following replacements
+func (r *Resolver) load(ctx context.Context, mv ModuleVersion, rules resolveRules) (*GoModFile, error) {
+	src := mv
+	if repl, dir, ok := rules.replacement(mv.Path, mv.Version); ok {
+		src = repl
+		if dir != "" {
+			src.Path = dir
+		}
+	}
+
+	r.mu.Lock()
+	f, ok := r.mods[src]
+	r.mu.Unlock()
+	if ok {
+		return f, nil
+	}
+
+	var data []byte
+	var err error
+	if src.Version == "" {
+		data, err = os.ReadFile(filepath.Join(src.Path, "go.mod"))
+	} else {
+		data, err = r.fetcher.fetchModFile(ctx, src.Path, src.Version)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if f, err = ParseGoModData(data); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.mods[src] = f
+	r.mu.Unlock()
+	return f, nil
+}
+
+// goVersionAtLeast compares go directive versions such as "1.21" or "1.22.3".
+// A missing go directive means the module predates 1.17.
+func goVersionAtLeast(v, min string) bool {
+	if v == "" {
+		return false
+	}
+	a := strings.Split(v, ".")
+	b := strings.Split(min, ".")
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			// drop prerelease suffixes like 1.21rc1
+			digits := a[i]
+			if j := strings.IndexFunc(digits, func(r rune) bool { return r < '0' || r > '9' }); j >= 0 {
+				digits = digits[:j]
+			}
+			x, _ = strconv.Atoi(digits)
+		}
+		if i < len(b) {
+			y, _ = strconv.Atoi(b[i])
+		}
+		if x != y {
+			return x > y
+		}
+	}
+	return true
+}
@@ -0,0 +1,115 @@
+package audit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeModCache populates a fake GOMODCACHE with go.mod files keyed by
+// "path@version"
+func writeModCache(t *testing.T, mods map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("GOMODCACHE", dir)
+	for key, content := range mods {
+		mv := splitModuleVersion(key)
+		p, err := modCachePath(dir, mv.Path, mv.Version, "mod")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestResolverResolve(t *testing.T) {
+	writeModCache(t, map[string]string{
+		"example.com/a@v1.0.0": "module example.com/a\ngo 1.20\nrequire example.com/c v1.1.0\n",
+		"example.com/b@v1.0.0": "module example.com/b\ngo 1.16\nrequire (\n\texample.com/c v1.2.0\n\texample.com/Upper v1.0.0\n)\n",
+		// c is only required by a pruned module at v1.1.0, so its go.mod
+		// is never loaded at that version
+		"example.com/c@v1.2.0":     "module example.com/c\ngo 1.20\nrequire example.com/d v1.0.0\n",
+		"example.com/Upper@v1.0.0": "module example.com/Upper\ngo 1.20\nrequire example.com/bad v1.0.0\n",
+		"example.com/fork@v1.0.1":  "module example.com/fork\ngo 1.20\n",
+	})
+
+	project := t.TempDir()
+	gomod := `module example.com/app
+
+go 1.21
+
+require (
+	example.com/a v1.0.0
+	example.com/b v1.0.0
+	example.com/e v1.0.0 // indirect
+)
+
+exclude example.com/bad v1.0.0
+
+replace example.com/e => example.com/fork v1.0.1
+`
+	if err := os.WriteFile(filepath.Join(project, "go.mod"), []byte(gomod), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	modules, graph, err := NewResolver(NewFetcher(AuditConfig{})).Resolve(context.Background(), project)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+
+	got := make(map[string]Module)
+	for _, m := range modules {
+		got[m.Path] = m
+	}
+	want := map[string]string{
+		"example.com/a":     "v1.0.0",
+		"example.com/b":     "v1.0.0",
+		"example.com/c":     "v1.2.0",
+		"example.com/d":     "v1.0.0",
+		"example.com/e":     "v1.0.0",
+		"example.com/Upper": "v1.0.0",
+	}
+	if len(modules) != len(want)+1 || !modules[0].Main {
+		t.Fatalf("Resolve() = %+v", modules)
+	}
+	for path, version := range want {
+		if got[path].Version != version {
+			t.Errorf("%s selected %q, want %q", path, got[path].Version, version)
+		}
+	}
+	if !got["example.com/c"].Indirect || got["example.com/a"].Indirect {
+		t.Errorf("indirect flags wrong: a=%v c=%v", got["example.com/a"].Indirect, got["example.com/c"].Indirect)
+	}
+	if r := got["example.com/e"].Replace; r == nil || r.Path != "example.com/fork" {
+		t.Errorf("example.com/e replacement = %+v", r)
+	}
+
+	deps := graph.Dependencies("example.com/b")
+	if len(deps) != 3 { // c, d, Upper
+		t.Errorf("Dependencies(b) = %v", deps)
+	}
+}
+
+func TestGoVersionAtLeast(t *testing.T) {
+	tests := []struct {
+		v, min string
+		want   bool
+	}{
+		{"1.17", "1.17", true},
+		{"1.21.3", "1.17", true},
+		{"1.16", "1.17", false},
+		{"1.21rc1", "1.17", true},
+		{"", "1.17", false},
+	}
+	for _, tt := range tests {
+		if got := goVersionAtLeast(tt.v, tt.min); got != tt.want {
+			t.Errorf("goVersionAtLeast(%q, %q) = %v, want %v", tt.v, tt.min, got, tt.want)
+		}
+	}
+}
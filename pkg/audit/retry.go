@@ -0,0 +1,322 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultMaxRetries is how often a failed request is retried unless
+	// AuditConfig.MaxRetries says otherwise
+	DefaultMaxRetries = 3
+
+	// attemptTimeout bounds a single request, waits between retries excluded
+	attemptTimeout = 10 * time.Second
+	// maxRetryWait is the longest the fetcher waits for a server to accept
+	// requests again; hosts that need longer are skipped until then
+	maxRetryWait = time.Minute
+	// maxBackoff caps the exponential backoff
+	maxBackoff = 30 * time.Second
+)
+
+// errRateLimited is returned without asking a host whose rate limit is
+// exhausted for longer than maxRetryWait
+var errRateLimited = errors.New("rate limit exhausted")
+
+// defaultRateLimits are requests per second by host. GitHub asks clients
+// not to hammer its API with concurrent requests.
+var defaultRateLimits = map[string]float64{
+	"api.github.com": 10,
+}
+
+// retryTransport spaces requests to each host by its rate limit and retries
+// GET and HEAD requests that fail with a network error, 429 or 5xx, and
+// GitHub's rate limit 403s. Retry-After and rate limit reset headers are
+// honored, otherwise the delay backs off exponentially with jitter.
+type retryTransport struct {
+	base       http.RoundTripper
+	maxRetries int
+	// baseDelay is the first backoff delay
+	baseDelay time.Duration
+	verbose   bool
+	limits    map[string]float64
+
+	mu    sync.Mutex
+	hosts map[string]*hostLimit
+}
+
+// hostLimit is the request schedule of one host
+type hostLimit struct {
+	// next is the earliest start of the next request
+	next     time.Time
+	interval time.Duration
+	// blocked is when an exhausted rate limit resets
+	blocked time.Time
+	warned  bool
+}
+
+// newRetryTransport applies the retry and rate limit settings of the config
+func newRetryTransport(config AuditConfig, base http.RoundTripper) *retryTransport {
+	limits := make(map[string]float64)
+	for host, rps := range defaultRateLimits {
+		limits[host] = rps
+	}
+	for host, rps := range config.RateLimits {
+		limits[host] = rps
+	}
+	retries := config.MaxRetries
+	switch {
+	case retries == 0:
+		retries = DefaultMaxRetries
+	case retries < 0:
+		retries = 0
+	}
+	return &retryTransport{
+		base:       base,
+		maxRetries: retries,
+		baseDelay:  500 * time.Millisecond,
+		verbose:    config.Verbose,
+		limits:     limits,
+		hosts:      make(map[string]*hostLimit),
+	}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	host := req.URL.Host
+	for attempt := 0; ; attempt++ {
+		if err := t.wait(ctx, host); err != nil {
+			return nil, err
+		}
+		resp, err := t.attempt(req)
+		if resp != nil {
+			t.observe(host, resp)
+		}
+
+		retry, delay, reason := t.retryDelay(req, resp, err, attempt)
+		if !retry {
+			return resp, err
+		}
+		if delay > maxRetryWait {
+			// Waiting that long is worse than going without the data
+			t.logf("Not retrying %s %s: %s, retry after %s", req.Method, redactURL(req.URL.String()), reason, delay.Round(time.Second))
+			t.block(host, time.Now().Add(delay))
+			return resp, err
+		}
+		t.logf("Retrying %s %s in %s: %s (retry %d of %d)", req.Method, redactURL(req.URL.String()), delay.Round(time.Millisecond), reason, attempt+1, t.maxRetries)
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+			resp.Body.Close()
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// attempt sends req once, with its own timeout
+func (t *retryTransport) attempt(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), attemptTimeout)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelBody ends the attempt's context once the body is read
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// retryDelay decides whether to retry after an attempt, how long to wait
+// first and why
+func (t *retryTransport) retryDelay(req *http.Request, resp *http.Response, err error, attempt int) (bool, time.Duration, string) {
+	if attempt >= t.maxRetries || req.Context().Err() != nil {
+		return false, 0, ""
+	}
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false, 0, ""
+	}
+	if err != nil {
+		return true, t.backoff(attempt), err.Error()
+	}
+
+	reason := "status " + strconv.Itoa(resp.StatusCode)
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+	case http.StatusForbidden:
+		// GitHub rejects requests over its rate limits with a 403
+		if !rateLimitExhausted(resp) && resp.Header.Get("Retry-After") == "" {
+			return false, 0, ""
+		}
+		reason = "rate limited"
+	default:
+		return false, 0, ""
+	}
+
+	if d, ok := retryAfter(resp, time.Now()); ok {
+		return true, d, reason
+	}
+	if reset, ok := rateLimitReset(resp); ok && rateLimitExhausted(resp) {
+		return true, time.Until(reset), reason + ", rate limit exhausted"
+	}
+	return true, t.backoff(attempt), reason
+}
+
+// backoff doubles the delay with every attempt and adds jitter, so
+// concurrent requests do not retry in lockstep
+func (t *retryTransport) backoff(attempt int) time.Duration {
+	d := t.baseDelay << attempt
+	if d > maxBackoff || d <= 0 {
+		d = maxBackoff
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// wait blocks until host may be asked again
+func (t *retryTransport) wait(ctx context.Context, host string) error {
+	t.mu.Lock()
+	h := t.host(host)
+	now := time.Now()
+	start := now
+	if h.next.After(start) {
+		start = h.next
+	}
+	if h.blocked.After(start) {
+		if h.blocked.Sub(now) > maxRetryWait {
+			until := h.blocked
+			t.mu.Unlock()
+			return fmt.Errorf("%s: %w until %s", host, errRateLimited, until.Local().Format("15:04:05"))
+		}
+		t.logf("Waiting %s for the %s rate limit to reset", h.blocked.Sub(now).Round(time.Second), host)
+		start = h.blocked
+	}
+	if h.interval > 0 {
+		h.next = start.Add(h.interval)
+	}
+	t.mu.Unlock()
+	return sleep(ctx, time.Until(start))
+}
+
+// observe records an exhausted rate limit, so the following requests wait
+// for it to reset instead of being rejected
+func (t *retryTransport) observe(host string, resp *http.Response) {
+	if !rateLimitExhausted(resp) {
+		return
+	}
+	if reset, ok := rateLimitReset(resp); ok {
+		t.block(host, reset)
+	}
+}
+
+// block holds requests to host until the given time. Hosts blocked for
+// longer than maxRetryWait are skipped with a warning.
+func (t *retryTransport) block(host string, until time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	h := t.host(host)
+	if !until.After(h.blocked) {
+		return
+	}
+	h.blocked = until
+	if time.Until(until) > maxRetryWait && !h.warned {
+		h.warned = true
+		fmt.Fprintf(os.Stderr, "Warning: %s rate limit exhausted until %s, skipping its requests until then\n", host, until.Local().Format("15:04:05"))
+	} else {
+		t.logf("%s rate limit exhausted, requests wait until %s", host, until.Local().Format("15:04:05"))
+	}
+}
+
+// host returns the schedule of a host; t.mu must be held
+func (t *retryTransport) host(host string) *hostLimit {
+	h, ok := t.hosts[host]
+	if !ok {
+		h = &hostLimit{}
+		if rps := t.limits[host]; rps > 0 {
+			h.interval = time.Duration(float64(time.Second) / rps)
+		}
+		t.hosts[host] = h
+	}
+	return h
+}
+
+func (t *retryTransport) logf(format string, args ...interface{}) {
+	if t.verbose {
+		fmt.Fprintf(os.Stderr, format+"\n", args...)
+	}
+}
+
+// retryAfter reads a Retry-After header in seconds or as an HTTP date
+func retryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		if d := at.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// rateLimitExhausted reports whether the primary rate limit is used up,
+// from GitHub's X-RateLimit-Remaining or GitLab's RateLimit-Remaining
+func rateLimitExhausted(resp *http.Response) bool {
+	v := resp.Header.Get("X-RateLimit-Remaining")
+	if v == "" {
+		v = resp.Header.Get("RateLimit-Remaining")
+	}
+	return v == "0"
+}
+
+// rateLimitReset reads when the rate limit window resets, in Unix seconds
+func rateLimitReset(resp *http.Response) (time.Time, bool) {
+	v := resp.Header.Get("X-RateLimit-Reset")
+	if v == "" {
+		v = resp.Header.Get("RateLimit-Reset")
+	}
+	secs, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(secs, 0), true
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,139 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryTransport(t *testing.T) {
+	reset := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	tests := []struct {
+		name     string
+		failures int
+		header   map[string]string
+		status   int
+		want     int
+		requests int32
+	}{
+		{"recovers from 503", 2, nil, http.StatusServiceUnavailable, http.StatusOK, 3},
+		{"honors Retry-After", 1, map[string]string{"Retry-After": "0"}, http.StatusTooManyRequests, http.StatusOK, 2},
+		{"gives up after the retries", 10, nil, http.StatusBadGateway, http.StatusBadGateway, 3},
+		{"not found is final", 10, nil, http.StatusNotFound, http.StatusNotFound, 1},
+		{"plain 403 is final", 10, nil, http.StatusForbidden, http.StatusForbidden, 1},
+		{"exhausted rate limit", 10, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": reset}, http.StatusForbidden, http.StatusForbidden, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if int(requests.Add(1)) <= tt.failures {
+					for k, v := range tt.header {
+						w.Header().Set(k, v)
+					}
+					w.WriteHeader(tt.status)
+					return
+				}
+				w.Write([]byte("ok"))
+			}))
+			defer srv.Close()
+
+			rt := newRetryTransport(AuditConfig{MaxRetries: 2}, http.DefaultTransport)
+			rt.baseDelay = time.Millisecond
+			client := &http.Client{Transport: rt}
+			resp, err := client.Get(srv.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want || requests.Load() != tt.requests {
+				t.Errorf("status %d after %d requests, want %d after %d", resp.StatusCode, requests.Load(), tt.want, tt.requests)
+			}
+		})
+	}
+}
+
+func TestRetryTransportRateLimit(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 2 {
+			// The last request of the window still succeeds
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		}
+	}))
+	defer srv.Close()
+	host := srv.Listener.Addr().String()
+
+	rt := newRetryTransport(AuditConfig{RateLimits: map[string]float64{host: 50}}, http.DefaultTransport)
+	client := &http.Client{Transport: rt}
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("two requests at 50/s took %s", elapsed)
+	}
+
+	// The exhausted limit is not asked again until it resets
+	_, err := client.Get(srv.URL)
+	if !errors.Is(err, errRateLimited) || requests.Load() != 2 {
+		t.Errorf("Get() = %v after %d requests, want errRateLimited after 2", err, requests.Load())
+	}
+
+	// Canceled requests stop waiting
+	rt = newRetryTransport(AuditConfig{RateLimits: map[string]float64{host: 0.01}}, http.DefaultTransport)
+	rt.wait(context.Background(), host)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := rt.wait(ctx, host); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("wait() = %v, want the context error", err)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"", 0, false},
+		{"120", 2 * time.Minute, true},
+		{"Mon, 01 Jan 2024 12:00:30 GMT", 30 * time.Second, true},
+		{"Mon, 01 Jan 2024 11:00:00 GMT", 0, true},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{Header: http.Header{}}
+		resp.Header.Set("Retry-After", tt.value)
+		if got, ok := retryAfter(resp, now); got != tt.want || ok != tt.ok {
+			t.Errorf("retryAfter(%q) = %s, %v, want %s, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	rt := newRetryTransport(AuditConfig{}, http.DefaultTransport)
+	for attempt := 0; attempt < 10; attempt++ {
+		max := rt.baseDelay << attempt
+		if max > maxBackoff {
+			max = maxBackoff
+		}
+		if d := rt.backoff(attempt); d < max/2 || d > max {
+			t.Errorf("backoff(%d) = %s, want between %s and %s", attempt, d, max/2, max)
+		}
+	}
+	if rt.maxRetries != DefaultMaxRetries {
+		t.Errorf("maxRetries = %d, want %d", rt.maxRetries, DefaultMaxRetries)
+	}
+}
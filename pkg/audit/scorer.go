@@ -12,34 +12,45 @@ func CalculateHealthScore(metadata *ModuleMetadata, config ScoringConfig) int {
 	}
 
 	recencyScore := calculateRecencyScore(metadata.LastCommitDate)
-	// For version frequency, we'd need more historical data, but let's use VersionCount as a proxy for maturity
-	// or if we had commit frequency.
-	// Let's assume we have some basic metrics.
+	// Release cadence when the version timestamps are known, otherwise the
+	// version count as a proxy for maturity
+	versionScore := calculateVersionScore(metadata)
 	
-	// If we don't have repo metadata, we rely heavily on recency and version count
-	versionScore := calculateVersionScore(metadata.VersionCount)
-	
-	// If we have repo metadata
-	commitScore := 0.0
+	// Forge statistics count when a repository was looked up or a source
+	// provided them, with or without a URL. Otherwise activity is assumed
+	// neutral and there is no community signal.
+	commitScore := 50.0
 	communityScore := 0.0
-	
-	if metadata.RepositoryURL != "" {
+	if metadata.RepositoryURL != "" || metadata.provides(SignalRepository) {
 		commitScore = calculateCommitScore(metadata.CommitFrequency)
-		communityScore = calculateCommunityScore(metadata.Stars, metadata.Contributors)
-	} else {
-		// If no repo metadata, re-distribute weights or just use what we have?
-		// For now, let's just average what we have, effectively treating missing as 0 or neutral?
-		// Better to treat missing as neutral (50) or ignore the weight?
-		// Let's treat missing as 0 for "Community" (no signal) but maybe neutral for activity if we only have publish date.
-		// Actually, if we only have proxy info, we only have Recency and VersionCount.
-		commitScore = 50 // Neutral assumption
-		communityScore = 0 // No signal
-	}
-
-	totalScore := (float64(recencyScore) * config.RecencyWeight) +
-		(float64(versionScore) * config.VersionFreqWeight) +
-		(commitScore * config.CommitActivityWeight) +
-		(communityScore * config.CommunityWeight)
+		communityScore = calculateCommunityScore(metadata)
+	}
+	repoKnown := !metadata.IsUnknown(SignalRepository)
+
+	// Signals that could not be determined are left out, and the weights of
+	// the others scaled up to make up for them
+	components := []struct {
+		score  float64
+		weight float64
+		known  bool
+	}{
+		{float64(recencyScore), config.RecencyWeight, !metadata.IsUnknown(SignalLastRelease)},
+		{float64(versionScore), config.VersionFreqWeight, !metadata.IsUnknown(SignalReleaseHistory)},
+		{commitScore, config.CommitActivityWeight, repoKnown && !metadata.IsUnknown(SignalCommits)},
+		{communityScore, config.CommunityWeight, repoKnown && !(metadata.IsUnknown(SignalStars) && metadata.IsUnknown(SignalContributors))},
+	}
+	totalScore, totalWeight, knownWeight := 0.0, 0.0, 0.0
+	for _, c := range components {
+		totalWeight += c.weight
+		if c.known {
+			totalScore += c.score * c.weight
+			knownWeight += c.weight
+		}
+	}
+	if knownWeight == 0 {
+		return 0
+	}
+	totalScore *= totalWeight / knownWeight
 
 	// Normalize to 0-100 int
 	score := int(math.Round(totalScore))
@@ -67,7 +78,14 @@ func calculateRecencyScore(lastDate time.Time) int {
 	return int(score)
 }
 
-func calculateVersionScore(count int) int {
+func calculateVersionScore(metadata *ModuleMetadata) int {
+	if metadata.FirstRelease.IsZero() {
+		return calculateVersionCountScore(metadata.VersionCount)
+	}
+	return calculateCadenceScore(metadata, time.Now())
+}
+
+func calculateVersionCountScore(count int) int {
 	// More versions generally means more maturity, up to a point
 	// 0 = 0
 	// 10+ = 100
@@ -77,6 +95,28 @@ func calculateVersionScore(count int) int {
 	return count * 5
 }
 
+func calculateCadenceScore(metadata *ModuleMetadata, now time.Time) int {
+	// Releases in the last year, half the score
+	// 0 = 0
+	// 4+ = 100
+	recent := math.Min(float64(metadata.ReleasesLastYear)*25, 100)
+
+	// Typical time between releases, a quarter
+	// 90 days or less = 100
+	// 2 years or more = 0
+	interval := 0.0
+	if metadata.MedianReleaseInterval > 0 {
+		interval = 100 * (730 - metadata.MedianReleaseInterval) / (730 - 90)
+		interval = math.Max(0, math.Min(interval, 100))
+	}
+
+	// Maturity, a quarter: a module released for 2+ years has proven itself
+	years := now.Sub(metadata.FirstRelease).Hours() / 24 / 365
+	maturity := math.Max(0, math.Min(years*50, 100))
+
+	return int(math.Round(0.5*recent + 0.25*interval + 0.25*maturity))
+}
+
 func calculateCommitScore(commitsPerMonth float64) float64 {
 	// 0 = 0
 	// 10/month = 100
@@ -87,21 +127,46 @@ func calculateCommitScore(commitsPerMonth float64) float64 {
 	return score
 }
 
-func calculateCommunityScore(stars, contributors int) float64 {
+func calculateCommunityScore(metadata *ModuleMetadata) float64 {
 	// Simple heuristic
 	// 1000 stars = 100
 	// 50 contributors = 100
+	// A part the forge does not report is left out of the average
 	
-	starScore := float64(stars) / 10.0 // 1000 stars -> 100
-	contribScore := float64(contributors) * 2.0 // 50 contribs -> 100
+	var parts []float64
+	if !metadata.IsUnknown(SignalStars) {
+		parts = append(parts, float64(metadata.Stars) / 10.0) // 1000 stars -> 100
+	}
+	if !metadata.IsUnknown(SignalContributors) {
+		parts = append(parts, float64(metadata.Contributors) * 2.0) // 50 contribs -> 100
+	}
+	if len(parts) == 0 {
+		return 0
+	}
 	
-	score := (starScore + contribScore) / 2.0
+	score := 0.0
+	for _, p := range parts {
+		score += p / float64(len(parts))
+	}
 	if score > 100 {
 		return 100
 	}
 	return score
 }
 
+// CategorizeModule is CategorizeHealth for a module's metadata: an archived
+// or deprecated module is Risky no matter how recent its last release is,
+// and one without any known signal is Unknown
+func CategorizeModule(metadata *ModuleMetadata, score int, config ScoringConfig) HealthCategory {
+	if metadata != nil && (metadata.Archived || metadata.Deprecated != "") {
+		return Risky
+	}
+	if metadata != nil && metadata.IsUnknown(SignalLastRelease) && metadata.IsUnknown(SignalReleaseHistory) && metadata.IsUnknown(SignalRepository) {
+		return Unknown
+	}
+	return CategorizeHealth(score, config)
+}
+
 // CategorizeHealth maps a score to a health category
 func CategorizeHealth(score int, config ScoringConfig) HealthCategory {
 	if score >= config.HealthyThreshold {
@@ -1,6 +1,7 @@
 package audit
 
 import (
+	"context"
 	"testing"
 	"time"
 )
@@ -58,6 +59,56 @@ func TestCalculateHealthScore(t *testing.T) {
 	}
 }
 
+func TestCalculateCadenceScore(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		metadata *ModuleMetadata
+		wantMin  int
+		wantMax  int
+	}{
+		{
+			name: "Regular Releases",
+			metadata: &ModuleMetadata{
+				ReleasesLastYear:      6,
+				MedianReleaseInterval: 45,
+				FirstRelease:          now.AddDate(-5, 0, 0),
+			},
+			wantMin: 100,
+			wantMax: 100,
+		},
+		{
+			name: "Abandoned",
+			metadata: &ModuleMetadata{
+				ReleasesLastYear:      0,
+				MedianReleaseInterval: 900,
+				FirstRelease:          now.AddDate(-6, 0, 0),
+			},
+			wantMin: 25,
+			wantMax: 25,
+		},
+		{
+			name: "Brand New",
+			metadata: &ModuleMetadata{
+				ReleasesLastYear: 1,
+				FirstRelease:     now.AddDate(0, -1, 0),
+			},
+			wantMin: 10,
+			wantMax: 20,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score := calculateCadenceScore(tt.metadata, now)
+			if score < tt.wantMin || score > tt.wantMax {
+				t.Errorf("calculateCadenceScore() = %v, want between %v and %v", score, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
 func TestCategorizeHealth(t *testing.T) {
 	config := DefaultScoringConfig()
 
@@ -79,3 +130,102 @@ func TestCategorizeHealth(t *testing.T) {
 		})
 	}
 }
+
+func TestCategorizeModule(t *testing.T) {
+	config := DefaultScoringConfig()
+
+	tests := []struct {
+		name     string
+		metadata *ModuleMetadata
+		score    int
+		want     HealthCategory
+	}{
+		{"healthy", &ModuleMetadata{}, 80, Healthy},
+		{"no metadata", nil, 60, Warning},
+		{"archived", &ModuleMetadata{Archived: true}, 90, Risky},
+		{"deprecated", &ModuleMetadata{Deprecated: "use example.com/new"}, 90, Risky},
+		{"nothing known", unknownMetadata(), 0, Unknown},
+		{"release date known", &ModuleMetadata{Unknown: []string{SignalReleaseHistory, SignalRepository}}, 80, Healthy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CategorizeModule(tt.metadata, tt.score, config); got != tt.want {
+				t.Errorf("CategorizeModule() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateHealthScoreUnknown(t *testing.T) {
+	config := DefaultScoringConfig()
+	active := ModuleMetadata{
+		LastCommitDate: time.Now(),
+		VersionCount:   20,
+	}
+
+	// Without repository statistics, activity counts as neutral and the
+	// community as zero
+	if got := CalculateHealthScore(&active, config); got != 70 {
+		t.Errorf("CalculateHealthScore() = %d, want 70", got)
+	}
+
+	// Unknown statistics are left out instead
+	unknownRepo := active
+	unknownRepo.Unknown = []string{SignalRepository}
+	if got := CalculateHealthScore(&unknownRepo, config); got != 99 {
+		t.Errorf("CalculateHealthScore() with unknown repository = %d, want 99", got)
+	}
+
+	onlyDate := active
+	onlyDate.LastCommitDate = time.Now().AddDate(0, 0, -180)
+	onlyDate.Unknown = []string{SignalReleaseHistory, SignalRepository}
+	if got := CalculateHealthScore(&onlyDate, config); got < 35 || got > 37 {
+		t.Errorf("CalculateHealthScore() with only the release date = %d, want about 36", got)
+	}
+
+	if got := CalculateHealthScore(unknownMetadata(), config); got != 0 {
+		t.Errorf("CalculateHealthScore() with nothing known = %d, want 0", got)
+	}
+}
+
+func TestCalculateHealthScoreForgeStatsWithoutURL(t *testing.T) {
+	config := DefaultScoringConfig()
+	catalog := fakeSource{name: "catalog", meta: &ModuleMetadata{
+		LastCommitDate:  time.Now(),
+		VersionCount:    25,
+		CommitFrequency: 10,
+		Stars:           1000,
+		Contributors:    50,
+	}}
+	meta, err := SourceChain{catalog}.FetchModuleMetadata(context.Background(), "example.com/mod", "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	withURL := *meta
+	withURL.RepositoryURL = "https://git.example.com/mod"
+
+	got, want := CalculateHealthScore(meta, config), CalculateHealthScore(&withURL, config)
+	if got != want || got < 90 {
+		t.Errorf("CalculateHealthScore() = %d without a URL, %d with one", got, want)
+	}
+}
+
+func TestCalculateHealthScorePartialRepository(t *testing.T) {
+	config := DefaultScoringConfig()
+	full := &ModuleMetadata{
+		LastCommitDate:  time.Now(),
+		VersionCount:    25,
+		CommitFrequency: 10,
+		Stars:           1000,
+		Contributors:    50,
+		RepositoryURL:   "https://github.com/example/repo",
+	}
+	// Uncounted contributors are left out instead of scoring zero
+	partial := *full
+	partial.Contributors = 0
+	partial.Unknown = []string{SignalContributors, SignalCommits}
+	if got, want := CalculateHealthScore(&partial, config), CalculateHealthScore(full, config); got != want {
+		t.Errorf("CalculateHealthScore() = %d with unknown counts, want %d", got, want)
+	}
+}
@@ -0,0 +1,158 @@
+package audit
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// semver is a parsed module version in the vMAJOR.MINOR.PATCH[-pre][+build]
+// form the go command uses
+type semver struct {
+	major, minor, patch string
+	prerelease          string
+	build               string
+}
+
+// parseSemver parses a canonical or shorthand (v1, v1.2) module version
+func parseSemver(v string) (semver, bool) {
+	var sv semver
+	if !strings.HasPrefix(v, "v") {
+		return sv, false
+	}
+	rest := v[1:]
+	if i := strings.IndexByte(rest, '+'); i >= 0 {
+		sv.build = rest[i+1:]
+		rest = rest[:i]
+	}
+	if i := strings.IndexByte(rest, '-'); i >= 0 {
+		sv.prerelease = rest[i+1:]
+		rest = rest[:i]
+		if sv.prerelease == "" {
+			return sv, false
+		}
+	}
+	parts := strings.Split(rest, ".")
+	if len(parts) > 3 {
+		return sv, false
+	}
+	for _, p := range parts {
+		if !isNumeric(p) || (len(p) > 1 && p[0] == '0') {
+			return sv, false
+		}
+	}
+	sv.major = parts[0]
+	sv.minor, sv.patch = "0", "0"
+	if len(parts) > 1 {
+		sv.minor = parts[1]
+	}
+	if len(parts) > 2 {
+		sv.patch = parts[2]
+	}
+	return sv, true
+}
+
+// compareVersions compares two module versions following semver precedence.
+// Invalid versions sort before all valid ones.
+func compareVersions(a, b string) int {
+	if a == b {
+		return 0
+	}
+	va, okA := parseSemver(a)
+	vb, okB := parseSemver(b)
+	switch {
+	case !okA && !okB:
+		return strings.Compare(a, b)
+	case !okA:
+		return -1
+	case !okB:
+		return 1
+	}
+	if c := compareNumeric(va.major, vb.major); c != 0 {
+		return c
+	}
+	if c := compareNumeric(va.minor, vb.minor); c != 0 {
+		return c
+	}
+	if c := compareNumeric(va.patch, vb.patch); c != 0 {
+		return c
+	}
+	return comparePrerelease(va.prerelease, vb.prerelease)
+}
+
+func comparePrerelease(a, b string) int {
+	// A release sorts after any of its prereleases
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+	pa := strings.Split(a, ".")
+	pb := strings.Split(b, ".")
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		if pa[i] == pb[i] {
+			continue
+		}
+		na, nb := isNumeric(pa[i]), isNumeric(pb[i])
+		switch {
+		case na && nb:
+			return compareNumeric(pa[i], pb[i])
+		case na:
+			return -1
+		case nb:
+			return 1
+		default:
+			return strings.Compare(pa[i], pb[i])
+		}
+	}
+	switch {
+	case len(pa) < len(pb):
+		return -1
+	case len(pa) > len(pb):
+		return 1
+	}
+	return 0
+}
+
+func compareNumeric(a, b string) int {
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// pseudoVersionRE matches the timestamp and revision suffix of the three
+// pseudo-version forms: v0.0.0-TIME-REV, vX.Y.Z-pre.0.TIME-REV and
+// vX.Y.(Z+1)-0.TIME-REV
+var pseudoVersionRE = regexp.MustCompile(`[-.](\d{14})-[0-9a-f]{12}(\+incompatible)?$`)
+
+// pseudoVersionTime returns the commit time encoded in a pseudo-version
+func pseudoVersionTime(v string) (time.Time, bool) {
+	m := pseudoVersionRE.FindStringSubmatch(v)
+	if m == nil {
+		return time.Time{}, false
+	}
+	t, err := time.Parse("20060102150405", m[1])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
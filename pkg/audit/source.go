@@ -0,0 +1,171 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// MetadataSource provides module metadata. The built-in *Fetcher is one;
+// internal catalogs, caches or test fakes can be others, see
+// AuditConfig.MetadataSources.
+//
+// A zero field counts as not set, unless the source names it in the
+// returned Sources with its own Name. That is how a source sets Archived to
+// false or a count to 0 over what later sources report.
+type MetadataSource interface {
+	// Name identifies the source in ModuleMetadata.Sources
+	Name() string
+	// FetchModuleMetadata returns what the source knows about a module
+	// version. A nil result without an error means it knows nothing.
+	FetchModuleMetadata(ctx context.Context, modulePath, version string) (*ModuleMetadata, error)
+}
+
+// Name implements MetadataSource for the proxy and forge metadata
+func (f *Fetcher) Name() string {
+	return "builtin"
+}
+
+// SourceChain merges the metadata of several sources. For every field the
+// first source that sets it wins, so earlier sources override later ones and
+// later ones fill in what earlier ones left out. The merged metadata records
+// which source each field came from in Sources. Signals no source set a
+// field of are Unknown.
+type SourceChain []MetadataSource
+
+// Name implements MetadataSource
+func (c SourceChain) Name() string {
+	return "chain"
+}
+
+// FetchModuleMetadata asks every source. Sources that fail are skipped; it
+// only fails when no source knows the module.
+func (c SourceChain) FetchModuleMetadata(ctx context.Context, modulePath, version string) (*ModuleMetadata, error) {
+	merged := &ModuleMetadata{}
+	found := false
+	var errs []string
+	for _, s := range c {
+		meta, err := s.FetchModuleMetadata(ctx, modulePath, version)
+		if err != nil {
+			errs = append(errs, s.Name()+": "+err.Error())
+			continue
+		}
+		if meta == nil {
+			continue
+		}
+		found = true
+		mergeMetadata(merged, meta, s.Name())
+	}
+	if !found {
+		if len(errs) == 0 {
+			return nil, fmt.Errorf("no metadata source knows %s@%s", modulePath, version)
+		}
+		return nil, fmt.Errorf("no metadata for %s@%s: %s", modulePath, version, strings.Join(errs, "; "))
+	}
+
+	// A signal one source could not determine may come from another; what
+	// none of them set is left out of the score rather than scored as zero
+	for _, signal := range []string{SignalLastRelease, SignalReleaseHistory, SignalRepository} {
+		if !merged.provides(signal) {
+			merged.Unknown = append(merged.Unknown, signal)
+		}
+	}
+	if merged.provides(SignalRepository) {
+		for _, signal := range []string{SignalStars, SignalContributors, SignalCommits} {
+			if !merged.provides(signal) {
+				merged.Unknown = append(merged.Unknown, signal)
+			}
+		}
+	}
+	return merged, nil
+}
+
+// signalFields are the metadata fields, by JSON name, behind each signal
+var signalFields = map[string][]string{
+	SignalLastRelease:    {"last_commit_date"},
+	SignalReleaseHistory: {"version_count", "releases_last_year", "median_release_interval_days", "first_release", "major_versions"},
+	SignalRepository:     {"stars", "forks", "open_issues", "commit_frequency", "contributors", "archived", "last_push"},
+	SignalStars:          {"stars"},
+	SignalContributors:   {"contributors"},
+	SignalCommits:        {"commit_frequency"},
+}
+
+// provides reports whether a source set any field of a signal
+func (m *ModuleMetadata) provides(signal string) bool {
+	for _, field := range signalFields[signal] {
+		if _, ok := m.Sources[field]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// declare marks the fields of a signal as set by source, zero or not,
+// except those of signals m reports unknown
+func (m *ModuleMetadata) declare(source, signal string) {
+	if m.Sources == nil {
+		m.Sources = make(map[string]string)
+	}
+	unknown := make(map[string]bool)
+	for _, s := range m.Unknown {
+		for _, field := range signalFields[s] {
+			unknown[field] = true
+		}
+	}
+	for _, field := range signalFields[signal] {
+		if !unknown[field] {
+			m.Sources[field] = source
+		}
+	}
+}
+
+// mergeMetadata copies the fields src sets and dst does not yet have, and
+// records where they came from. src sets the fields that are non-zero or
+// named in its Sources. Provenance src carries itself, e.g. from a nested
+// chain, is kept.
+func mergeMetadata(dst, src *ModuleMetadata, source string) {
+	dv := reflect.ValueOf(dst).Elem()
+	sv := reflect.ValueOf(src).Elem()
+	t := dv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == "unknown" || name == "sources" {
+			continue
+		}
+		if _, done := dst.Sources[name]; done {
+			continue
+		}
+		if _, declared := src.Sources[name]; sv.Field(i).IsZero() && !declared {
+			continue
+		}
+		dv.Field(i).Set(sv.Field(i))
+		if dst.Sources == nil {
+			dst.Sources = make(map[string]string)
+		}
+		dst.Sources[name] = source
+		if p := src.Sources[name]; p != "" {
+			dst.Sources[name] = p
+		}
+	}
+}
+
+// auditFetcher returns the *Fetcher among the configured metadata sources,
+// so the rest of the audit shares what it fetched, else a new one
+func auditFetcher(config AuditConfig) *Fetcher {
+	for _, s := range config.MetadataSources {
+		if f, ok := s.(*Fetcher); ok {
+			return f
+		}
+	}
+	return NewFetcher(config)
+}
+
+// metadataSource is what an audit scores modules on: the configured chain,
+// or the fetcher alone
+func metadataSource(config AuditConfig, fetcher *Fetcher) MetadataSource {
+	if len(config.MetadataSources) == 0 {
+		return fetcher
+	}
+	return SourceChain(config.MetadataSources)
+}
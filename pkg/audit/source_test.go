@@ -0,0 +1,124 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSource returns fixed metadata
+type fakeSource struct {
+	name string
+	meta *ModuleMetadata
+	err  error
+}
+
+func (s fakeSource) Name() string { return s.name }
+
+func (s fakeSource) FetchModuleMetadata(ctx context.Context, modulePath, version string) (*ModuleMetadata, error) {
+	return s.meta, s.err
+}
+
+func TestSourceChain(t *testing.T) {
+	released := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	catalog := fakeSource{name: "catalog", meta: &ModuleMetadata{
+		Stars:        1200,
+		VersionCount: 30,
+		Deprecated:   "use example.com/new",
+	}}
+	proxy := fakeSource{name: "proxy", meta: &ModuleMetadata{
+		LastCommitDate: released,
+		VersionCount:   12,
+		Unknown:        []string{SignalRepository},
+	}}
+	broken := fakeSource{name: "broken", err: errors.New("connection refused")}
+	empty := fakeSource{name: "empty"}
+
+	meta, err := SourceChain{broken, catalog, empty, proxy}.FetchModuleMetadata(context.Background(), "example.com/mod", "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Stars != 1200 || meta.VersionCount != 30 || !meta.LastCommitDate.Equal(released) || meta.Deprecated == "" {
+		t.Errorf("merged metadata = %+v", meta)
+	}
+	wantSources := map[string]string{
+		"stars":            "catalog",
+		"deprecated":       "catalog",
+		"last_commit_date": "proxy",
+		"version_count":    "catalog",
+	}
+	if len(meta.Sources) != len(wantSources) {
+		t.Errorf("Sources = %v, want %v", meta.Sources, wantSources)
+	}
+	for field, source := range wantSources {
+		if meta.Sources[field] != source {
+			t.Errorf("Sources[%q] = %q, want %q", field, meta.Sources[field], source)
+		}
+	}
+	// The catalog provides the stars the proxy source could not determine,
+	// but nobody knows the rest of the repository statistics
+	if want := []string{SignalContributors, SignalCommits}; !reflect.DeepEqual(meta.Unknown, want) {
+		t.Errorf("Unknown = %v, want %v", meta.Unknown, want)
+	}
+
+	// Provenance survives nesting
+	meta, err = SourceChain{SourceChain{catalog}, proxy}.FetchModuleMetadata(context.Background(), "example.com/mod", "v1.0.0")
+	if err != nil || meta.Sources["stars"] != "catalog" {
+		t.Errorf("nested chain = %+v, %v", meta, err)
+	}
+
+	meta, err = SourceChain{proxy}.FetchModuleMetadata(context.Background(), "example.com/mod", "v1.0.0")
+	if err != nil || !meta.IsUnknown(SignalRepository) {
+		t.Errorf("proxy only = %+v, %v, want the repository unknown", meta, err)
+	}
+
+	// Without a proxy source the release date is unknown, not ancient
+	meta, err = SourceChain{catalog}.FetchModuleMetadata(context.Background(), "example.com/mod", "v1.0.0")
+	if err != nil || !meta.IsUnknown(SignalLastRelease) || meta.IsUnknown(SignalReleaseHistory) || meta.IsUnknown(SignalRepository) {
+		t.Errorf("catalog only = %+v, %v, want the last release unknown", meta, err)
+	}
+
+	// Zero values override later sources when they are declared
+	unarchived := fakeSource{name: "override", meta: &ModuleMetadata{
+		Sources: map[string]string{"archived": "override", "stars": "override"},
+	}}
+	archived := fakeSource{name: "forge", meta: &ModuleMetadata{Archived: true, Stars: 50, Forks: 3}}
+	meta, err = SourceChain{unarchived, archived}.FetchModuleMetadata(context.Background(), "example.com/mod", "v1.0.0")
+	if err != nil || meta.Archived || meta.Stars != 0 || meta.Forks != 3 || meta.Sources["archived"] != "override" || meta.Sources["forks"] != "forge" {
+		t.Errorf("declared zero values = %+v, %v", meta, err)
+	}
+
+	if _, err := (SourceChain{broken, empty}).FetchModuleMetadata(context.Background(), "example.com/mod", "v1.0.0"); err == nil || !strings.Contains(err.Error(), "broken: connection refused") {
+		t.Errorf("FetchModuleMetadata() error = %v", err)
+	}
+}
+
+func TestAuditWithMetadataSources(t *testing.T) {
+	t.Setenv("GOMODCACHE", t.TempDir())
+	config := AuditConfig{
+		Scoring: DefaultScoringConfig(),
+		// Nothing but the fake source is consulted
+		Offline: true,
+		MetadataSources: []MetadataSource{fakeSource{name: "catalog", meta: &ModuleMetadata{
+			LastCommitDate: time.Now(),
+			VersionCount:   20,
+		}}},
+	}
+	results := AuditModuleList(context.Background(), config, []Module{{Path: "example.com/mod", Version: "v1.0.0"}})
+	if len(results) != 1 {
+		t.Fatalf("got %d results", len(results))
+	}
+	res := results[0]
+	if res.MetadataError != "" || res.HealthCategory != Healthy || res.Metadata.Sources["version_count"] != "catalog" {
+		t.Errorf("result = %+v, metadata %+v", res, res.Metadata)
+	}
+
+	fetcher := NewFetcher(config)
+	config.MetadataSources = append(config.MetadataSources, fetcher)
+	if auditFetcher(config) != fetcher {
+		t.Error("auditFetcher() did not reuse the configured fetcher")
+	}
+}
@@ -10,6 +10,9 @@ const (
 	Warning
 	Stale
 	Risky
+	// Unknown modules could not be scored, e.g. offline without their
+	// release dates
+	Unknown
 )
 
 func (h HealthCategory) String() string {
@@ -27,6 +30,19 @@ func (h HealthCategory) String() string {
 	}
 }
 
+// Scoring signals that can be unknown, see ModuleMetadata.Unknown
+const (
+	SignalLastRelease    = "last_release"    // release date of the version in use
+	SignalReleaseHistory = "release_history" // version list and cadence
+	SignalRepository     = "repository"      // forge statistics
+
+	// Parts of the forge statistics a forge may not report while the rest
+	// of the repository is known
+	SignalStars        = "stars"
+	SignalContributors = "contributors"
+	SignalCommits      = "recent_commits"
+)
+
 // LicenseRisk represents the risk level associated with a license
 type LicenseRisk int
 
@@ -56,25 +72,80 @@ func (l LicenseRisk) String() string {
 type ModuleHealth struct {
 	Path           string          `json:"path"`
 	Version        string          `json:"version"`
-	HealthScore    int             `json:"health_score"` // 0-100
+	ReplacedBy     string          `json:"replaced_by,omitempty"` // path[@version] the module is replaced with
+	HealthScore    int             `json:"health_score"`          // 0-100
 	HealthCategory HealthCategory  `json:"health_category"`
 	License        string          `json:"license"`
 	LicenseRisk    LicenseRisk     `json:"license_risk"`
-	FootprintRisk  float64         `json:"footprint_risk"`
+	FootprintRisk  float64         `json:"footprint_risk"` // 0-100
 	LastPublished  time.Time       `json:"last_published"`
 	TransitiveDeps int             `json:"transitive_deps"`
+	MaxDepth       int             `json:"max_depth"`
+	ExclusiveDeps  int             `json:"exclusive_deps"` // deps that exist only because of this module
 	DirectDep      bool            `json:"direct_dep"`
+	Reachability   Reachability    `json:"reachability"`          // whether the module is linked into binaries, test-only or graph-only
+	Platforms      []string        `json:"platforms,omitempty"`   // build targets the module is linked into, when known
+	RequiredBy     []string        `json:"required_by,omitempty"` // main modules that pull this module in (workspace or recursive audits)
+	LatestVersion  string          `json:"latest_version,omitempty"`
+	LatestMinor    string          `json:"latest_minor,omitempty"` // highest release of the current major version
+	LatestPatch    string          `json:"latest_patch,omitempty"` // highest release of the current minor version
+	PatchesBehind  int             `json:"patches_behind"`
+	MinorsBehind   int             `json:"minors_behind"`
+	NewerMajor     string          `json:"newer_major,omitempty"`    // path@version of a newer major version
+	Libyear        float64         `json:"libyear"`                  // years between the used and the latest release
+	Retracted      bool            `json:"retracted"`                // the version is retracted by its author
+	Retraction     string          `json:"retraction,omitempty"`     // rationale the author gave for the retraction
+	MetadataError  string          `json:"metadata_error,omitempty"` // why metadata could not be fetched; the score is then incomplete
 	Metadata       *ModuleMetadata `json:"metadata,omitempty"`
 }
 
+// Outdated reports whether a newer patch, minor or major version exists
+func (m ModuleHealth) Outdated() bool {
+	return m.PatchesBehind > 0 || m.MinorsBehind > 0 || m.NewerMajor != ""
+}
+
 // ModuleMetadata contains raw metadata fetched from sources
 type ModuleMetadata struct {
 	RepositoryURL   string    `json:"repository_url"`
 	Stars           int       `json:"stars"`
 	Forks           int       `json:"forks"`
 	OpenIssues      int       `json:"open_issues"`
-	LastCommitDate  time.Time `json:"last_commit_date"`
+	LastCommitDate  time.Time `json:"last_commit_date"` // release date of the version in use
+	LastPush        time.Time `json:"last_push"`        // last push to any branch of the repository
 	CommitFrequency float64   `json:"commit_frequency"` // commits per month
 	Contributors    int       `json:"contributors"`
 	VersionCount    int       `json:"version_count"`
+
+	// Release cadence, from the timestamps of the tagged versions
+	ReleasesLastYear      int       `json:"releases_last_year"`
+	MedianReleaseInterval float64   `json:"median_release_interval_days"` // days between consecutive releases
+	FirstRelease          time.Time `json:"first_release"`
+	MajorVersions         int       `json:"major_versions"` // distinct major versions under this module path
+
+	// Signs of an abandoned module, which is Risky whatever its score
+	Archived   bool   `json:"archived"`             // the upstream repository is archived
+	Deprecated string `json:"deprecated,omitempty"` // deprecation message from the latest go.mod
+
+	// Unknown lists the signals that could not be determined. They are left
+	// out of the score instead of counting as zero.
+	Unknown []string `json:"unknown,omitempty"`
+
+	// Sources names the MetadataSource each field came from, by JSON field
+	// name. Zero values only count as set when they are listed here.
+	Sources map[string]string `json:"sources,omitempty"`
+}
+
+// IsUnknown reports whether a signal could not be determined
+func (m *ModuleMetadata) IsUnknown(signal string) bool {
+	for _, s := range m.Unknown {
+		if s == signal {
+			return true
+		}
+	}
+	return false
+}
+
+// unknownMetadata is the metadata of a module nothing is known about
+func unknownMetadata() *ModuleMetadata {
+	return &ModuleMetadata{Unknown: []string{SignalLastRelease, SignalReleaseHistory, SignalRepository}}
 }
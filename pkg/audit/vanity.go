@@ -0,0 +1,214 @@
+package audit
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// metaImport is a go-import meta tag: "prefix vcs repoRoot"
+type metaImport struct {
+	Prefix, VCS, RepoRoot string
+}
+
+// metaSource is a go-source meta tag: "prefix home directory file"
+type metaSource struct {
+	Prefix, Home string
+}
+
+// resolveRepoURL finds the repository of a module: directly for paths on a
+// known forge, by the gopkg.in conventions, or like the go command from the
+// go-import and go-source meta tags served at https://<path>?go-get=1
+func (f *Fetcher) resolveRepoURL(ctx context.Context, modulePath string) string {
+	if u := f.repoURL(modulePath); u != "" {
+		return u
+	}
+	if strings.HasPrefix(modulePath, "gopkg.in/") {
+		return gopkgInRepoURL(modulePath)
+	}
+	u, _ := memoize(ctx, &f.mu, f.vanity, modulePath, func() (string, error) {
+		escaped, err := escapeModulePath(modulePath)
+		if err != nil {
+			return "", err
+		}
+		// Failed lookups are cached too, hosts without meta tags are slow
+		// to ask again on every run
+		key := "vanity/" + escaped
+		if cached, fresh := f.cache.get(key, false); fresh {
+			return string(cached.Data), nil
+		}
+		u, err := f.fetchVanityRepoURL(ctx, modulePath)
+		if ctx.Err() == nil {
+			f.cache.put(key, &cacheEntry{Fetched: time.Now(), Data: []byte(u), NotFound: err != nil})
+		}
+		return u, err
+	})
+	return u
+}
+
+// fetchVanityRepoURL reads the meta tags of a vanity import path. A
+// repository on a known forge is preferred, so a go-source home on GitHub
+// wins over a go-import root on a mirror such as go.googlesource.com.
+func (f *Fetcher) fetchVanityRepoURL(ctx context.Context, modulePath string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", "https://"+modulePath+"?go-get=1", nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	// Like the go command, meta tags count whatever the status code
+	imports, sources, err := parseGoGetMeta(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse %s meta tags: %w", modulePath, err)
+	}
+
+	var candidates []string
+	if imp, ok := matchMetaImport(imports, modulePath); ok {
+		candidates = append(candidates, normalizeRepoRoot(imp.RepoRoot))
+	}
+	if src, ok := matchMetaSource(sources, modulePath); ok {
+		candidates = append(candidates, normalizeRepoRoot(src.Home))
+	}
+	for _, c := range candidates {
+		if u := f.repoURL(strings.TrimPrefix(c, "https://")); u != "" {
+			return u, nil
+		}
+	}
+	for _, c := range candidates {
+		if c != "" {
+			return c, nil
+		}
+	}
+	return "", fmt.Errorf("no go-import meta tag for %s", modulePath)
+}
+
+// parseGoGetMeta reads the go-import and go-source meta tags of an HTML
+// page, stopping at the end of its head
+func parseGoGetMeta(r io.Reader) ([]metaImport, []metaSource, error) {
+	d := xml.NewDecoder(r)
+	d.Strict = false
+	d.AutoClose = xml.HTMLAutoClose
+	d.Entity = xml.HTMLEntity
+	d.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		switch strings.ToLower(charset) {
+		case "utf-8", "ascii":
+			return input, nil
+		}
+		return nil, fmt.Errorf("cannot decode page in charset %q", charset)
+	}
+
+	var imports []metaImport
+	var sources []metaSource
+	for {
+		t, err := d.RawToken()
+		if err != nil {
+			// Broken markup after the tags is fine
+			if err == io.EOF || len(imports) > 0 || len(sources) > 0 {
+				return imports, sources, nil
+			}
+			return nil, nil, err
+		}
+		if e, ok := t.(xml.StartElement); ok && strings.EqualFold(e.Name.Local, "body") {
+			return imports, sources, nil
+		}
+		if e, ok := t.(xml.EndElement); ok && strings.EqualFold(e.Name.Local, "head") {
+			return imports, sources, nil
+		}
+		e, ok := t.(xml.StartElement)
+		if !ok || !strings.EqualFold(e.Name.Local, "meta") {
+			continue
+		}
+		fields := strings.Fields(attrValue(e.Attr, "content"))
+		switch attrValue(e.Attr, "name") {
+		case "go-import":
+			// A fourth field may name a subdirectory of the repository
+			if len(fields) >= 3 {
+				imports = append(imports, metaImport{Prefix: fields[0], VCS: fields[1], RepoRoot: fields[2]})
+			}
+		case "go-source":
+			if len(fields) >= 2 {
+				sources = append(sources, metaSource{Prefix: fields[0], Home: fields[1]})
+			}
+		}
+	}
+}
+
+func attrValue(attrs []xml.Attr, name string) string {
+	for _, a := range attrs {
+		if strings.EqualFold(a.Name.Local, name) {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// matchMetaImport returns the go-import tag with the longest prefix of
+// modulePath. "mod" entries point at a module proxy, not a repository.
+func matchMetaImport(imports []metaImport, modulePath string) (metaImport, bool) {
+	var best metaImport
+	found := false
+	for _, imp := range imports {
+		if imp.VCS != "mod" && hasPathPrefix(modulePath, imp.Prefix) && len(imp.Prefix) >= len(best.Prefix) {
+			best, found = imp, true
+		}
+	}
+	return best, found
+}
+
+// matchMetaSource returns the go-source tag with the longest prefix of
+// modulePath
+func matchMetaSource(sources []metaSource, modulePath string) (metaSource, bool) {
+	var best metaSource
+	found := false
+	for _, src := range sources {
+		if hasPathPrefix(modulePath, src.Prefix) && len(src.Prefix) >= len(best.Prefix) {
+			best, found = src, true
+		}
+	}
+	return best, found
+}
+
+// hasPathPrefix reports whether prefix is path or a parent of it
+func hasPathPrefix(path, prefix string) bool {
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// normalizeRepoRoot turns a repository root such as
+// ssh://git@example.com/x/y.git into https://example.com/x/y
+func normalizeRepoRoot(root string) string {
+	u, err := url.Parse(root)
+	if err != nil || u.Hostname() == "" {
+		return ""
+	}
+	p := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	if p == "" {
+		return ""
+	}
+	return "https://" + u.Hostname() + "/" + p
+}
+
+// gopkgInRepoURL applies the gopkg.in conventions: gopkg.in/pkg.vN is
+// github.com/go-pkg/pkg and gopkg.in/user/pkg.vN is github.com/user/pkg
+func gopkgInRepoURL(modulePath string) string {
+	prefix, _, _ := splitPathMajor(modulePath)
+	if prefix == modulePath {
+		return ""
+	}
+	parts := strings.Split(strings.TrimPrefix(prefix, "gopkg.in/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] != "":
+		return "https://github.com/go-" + parts[0] + "/" + parts[0]
+	case len(parts) == 2 && parts[0] != "" && parts[1] != "":
+		return "https://github.com/" + parts[0] + "/" + parts[1]
+	}
+	return ""
+}
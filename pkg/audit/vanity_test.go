@@ -0,0 +1,125 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseGoGetMeta(t *testing.T) {
+	page := `<!DOCTYPE html>
+<html>
+<head>
+<meta http-equiv="Content-Type" content="text/html; charset=utf-8"/>
+<meta name="go-import" content="go.uber.org/zap git https://github.com/uber-go/zap">
+<meta name="go-source" content="go.uber.org/zap https://github.com/uber-go/zap https://github.com/uber-go/zap/tree/master{/dir} https://github.com/uber-go/zap/tree/master{/dir}/{file}#L{line}">
+<meta name="go-import" content="go.uber.org/zap mod https://proxy.example.com">
+</head>
+<body>
+<meta name="go-import" content="ignored git https://example.com/ignored">
+Nothing to see here &mdash; <a href="https://pkg.go.dev/go.uber.org/zap">docs</a>
+</body>
+</html>`
+	imports, sources, err := parseGoGetMeta(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(imports) != 2 || imports[0] != (metaImport{"go.uber.org/zap", "git", "https://github.com/uber-go/zap"}) {
+		t.Errorf("imports = %+v", imports)
+	}
+	if len(sources) != 1 || sources[0].Home != "https://github.com/uber-go/zap" {
+		t.Errorf("sources = %+v", sources)
+	}
+
+	imp, ok := matchMetaImport(imports, "go.uber.org/zap")
+	if !ok || imp.VCS != "git" {
+		t.Errorf("matchMetaImport() = %+v, %v, want the git entry", imp, ok)
+	}
+	if _, ok := matchMetaImport(imports, "go.uber.org/zapx"); ok {
+		t.Error("go.uber.org/zap must not match go.uber.org/zapx")
+	}
+}
+
+func TestNormalizeRepoRoot(t *testing.T) {
+	tests := []struct {
+		root string
+		want string
+	}{
+		{"https://github.com/uber-go/zap", "https://github.com/uber-go/zap"},
+		{"https://gitlab.com/group/sub/widget.git", "https://gitlab.com/group/sub/widget"},
+		{"ssh://git@example.com:2222/x/y.git", "https://example.com/x/y"},
+		{"https://example.com/", ""},
+		{"not a url", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeRepoRoot(tt.root); got != tt.want {
+			t.Errorf("normalizeRepoRoot(%q) = %q, want %q", tt.root, got, tt.want)
+		}
+	}
+}
+
+func TestGopkgInRepoURL(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"gopkg.in/yaml.v3", "https://github.com/go-yaml/yaml"},
+		{"gopkg.in/check.v1", "https://github.com/go-check/check"},
+		{"gopkg.in/natefinch/lumberjack.v2", "https://github.com/natefinch/lumberjack"},
+		{"gopkg.in/src-d/go-git.v4", "https://github.com/src-d/go-git"},
+		{"gopkg.in/yaml", ""},
+	}
+	for _, tt := range tests {
+		if got := gopkgInRepoURL(tt.path); got != tt.want {
+			t.Errorf("gopkgInRepoURL(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestResolveRepoURL(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("go-get") != "1" {
+			t.Errorf("query = %s", r.URL.RawQuery)
+		}
+		host := r.Host
+		switch r.URL.Path {
+		case "/zap":
+			fmt.Fprintf(w, `<meta name="go-import" content="%s/zap git https://github.com/uber-go/zap.git">`, host)
+		case "/x/mod":
+			// The repository is on a mirror, its browsable home on GitHub
+			fmt.Fprintf(w, `<meta name="go-import" content="%s/x/mod git https://go.googlesource.com/mod">`, host)
+			fmt.Fprintf(w, `<meta name="go-source" content="%s/x/mod https://github.com/golang/mod/ https://github.com/golang/mod/tree/master{/dir}">`, host)
+		case "/cloud/storage":
+			// Submodules share the repository of the prefix
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, `<meta name="go-import" content="%s/cloud git https://git.example.com/cloud">`, host)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	f := NewFetcher(AuditConfig{})
+	f.client = srv.Client()
+	host := strings.TrimPrefix(srv.URL, "https://")
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"github.com/spf13/cobra", "https://github.com/spf13/cobra"},
+		{"gopkg.in/yaml.v3", "https://github.com/go-yaml/yaml"},
+		{host + "/zap", "https://github.com/uber-go/zap"},
+		{host + "/x/mod", "https://github.com/golang/mod"},
+		{host + "/cloud/storage", "https://git.example.com/cloud"},
+		{host + "/missing", ""},
+	}
+	for _, tt := range tests {
+		if got := f.resolveRepoURL(context.Background(), tt.path); got != tt.want {
+			t.Errorf("resolveRepoURL(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package audit
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ParseVendorModules parses a vendor/modules.txt file. Every "# path version"
+// line becomes a module whose Dir points at its vendored sources; "## explicit"
+// marks modules required by the main go.mod, the others are indirect.
+// Replacements ("=> new [version]") are kept on Module.Replace.
+func ParseVendorModules(path string) ([]Module, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	vendorDir := filepath.Dir(path)
+	var modules []Module
+	var cur *Module
+
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "## "):
+			if cur == nil {
+				continue
+			}
+			for _, attr := range strings.Split(strings.TrimPrefix(line, "## "), ";") {
+				attr = strings.TrimSpace(attr)
+				switch {
+				case attr == "explicit":
+					cur.Indirect = false
+				case strings.HasPrefix(attr, "go "):
+					cur.GoVersion = strings.TrimPrefix(attr, "go ")
+				}
+			}
+
+		case strings.HasPrefix(line, "# "):
+			cur = nil
+			fields := strings.Fields(strings.TrimPrefix(line, "# "))
+			old, repl, err := splitVendorReplacement(fields)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", path, lineNo, err)
+			}
+			// "# path => replacement" without a version only records a
+			// replace directive, it is not part of the build
+			if old.Version == "" {
+				continue
+			}
+			mod := Module{
+				Path:     old.Path,
+				Version:  old.Version,
+				Indirect: true,
+				Dir:      filepath.Join(vendorDir, filepath.FromSlash(old.Path)),
+			}
+			if repl != nil {
+				mod.Replace = &Module{Path: repl.Path, Version: repl.Version}
+			}
+			modules = append(modules, mod)
+			cur = &modules[len(modules)-1]
+		}
+		// Package lines need no handling
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return modules, nil
+}
+
+// splitVendorReplacement splits "path [version] [=> new [version]]"
+func splitVendorReplacement(fields []string) (ModuleVersion, *ModuleVersion, error) {
+	arrow := -1
+	for i, f := range fields {
+		if f == "=>" {
+			arrow = i
+			break
+		}
+	}
+	left := fields
+	var right []string
+	if arrow >= 0 {
+		left, right = fields[:arrow], fields[arrow+1:]
+	}
+	if len(left) < 1 || len(left) > 2 || (arrow >= 0 && (len(right) < 1 || len(right) > 2)) {
+		return ModuleVersion{}, nil, fmt.Errorf("malformed module line %q", strings.Join(fields, " "))
+	}
+
+	old := ModuleVersion{Path: left[0]}
+	if len(left) == 2 {
+		old.Version = left[1]
+	}
+	if arrow < 0 {
+		return old, nil, nil
+	}
+	repl := &ModuleVersion{Path: right[0]}
+	if len(right) == 2 {
+		repl.Version = right[1]
+	}
+	return old, repl, nil
+}
+
+// LoadVendorModules returns the build list of a vendored project: the main
+// module from go.mod followed by the modules in vendor/modules.txt. Direct
+// requirements marked // indirect in go.mod are reported as indirect.
+func LoadVendorModules(projectPath string) ([]Module, error) {
+	gomod := filepath.Join(projectPath, "go.mod")
+	main, err := ParseGoModFile(gomod)
+	if err != nil {
+		return nil, err
+	}
+	vendored, err := ParseVendorModules(filepath.Join(projectPath, "vendor", "modules.txt"))
+	if err != nil {
+		return nil, err
+	}
+
+	indirect := make(map[string]bool)
+	for _, req := range main.Require {
+		indirect[req.Path] = req.Indirect
+	}
+	modules := []Module{{
+		Path:      main.Module,
+		Main:      true,
+		Dir:       projectPath,
+		GoMod:     gomod,
+		GoVersion: main.Go,
+	}}
+	for _, m := range vendored {
+		if !m.Indirect && indirect[m.Path] {
+			m.Indirect = true
+		}
+		modules = append(modules, m)
+	}
+	return modules, nil
+}
+
+// vendorMetadata is the metadata that can be derived from a module without
+// network access. Pseudo-versions carry their commit time; nothing else about
+// the module's activity is known from vendored sources, so it is left out of
+// the score.
+func vendorMetadata(version string) *ModuleMetadata {
+	meta := unknownMetadata()
+	if t, ok := pseudoVersionTime(version); ok {
+		meta.LastCommitDate = t
+		meta.Unknown = []string{SignalReleaseHistory, SignalRepository}
+	}
+	return meta
+}
@@ -0,0 +1,103 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testModulesTxt = `# github.com/a/one v1.2.0
+## explicit; go 1.18
+github.com/a/one
+github.com/a/one/sub
+# github.com/b/two v0.0.0-20210102030405-abcdefabcdef
+github.com/b/two
+# github.com/c/three v1.0.0 => github.com/fork/three v1.0.1
+## explicit
+github.com/c/three
+# github.com/d/four v1.1.0 => ../four
+## explicit
+github.com/d/four
+# github.com/e/unused => ../unused
+`
+
+func TestLoadVendorModules(t *testing.T) {
+	project := t.TempDir()
+	files := map[string]string{
+		"go.mod":                          "module example.com/app\n\ngo 1.21\n\nrequire (\n\tgithub.com/a/one v1.2.0\n\tgithub.com/c/three v1.0.0 // indirect\n\tgithub.com/d/four v1.1.0\n)\n",
+		"vendor/modules.txt":              testModulesTxt,
+		"vendor/github.com/a/one/LICENSE": "Permission is hereby granted, free of charge, to any person obtaining a copy",
+	}
+	for name, content := range files {
+		p := filepath.Join(project, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	modules, err := LoadVendorModules(project)
+	if err != nil {
+		t.Fatalf("LoadVendorModules() error = %v", err)
+	}
+	if len(modules) != 5 || !modules[0].Main {
+		t.Fatalf("LoadVendorModules() = %+v", modules)
+	}
+
+	one, two, three, four := modules[1], modules[2], modules[3], modules[4]
+	if one.Indirect || one.GoVersion != "1.18" || one.Dir != filepath.Join(project, "vendor", "github.com", "a", "one") {
+		t.Errorf("unexpected %+v", one)
+	}
+	if !two.Indirect {
+		t.Errorf("module without ## explicit should be indirect: %+v", two)
+	}
+	if !three.Indirect || three.Replace == nil || three.Replace.Version != "v1.0.1" {
+		t.Errorf("unexpected %+v", three)
+	}
+	if four.Indirect || four.Replace == nil || four.Replace.Path != "../four" {
+		t.Errorf("unexpected %+v", four)
+	}
+
+	if license, _ := DetectLicenseInDir(one.Dir); license != "MIT" {
+		t.Errorf("DetectLicenseInDir() = %q, want MIT", license)
+	}
+
+	meta := vendorMetadata(two.Version)
+	if want := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC); !meta.LastCommitDate.Equal(want) {
+		t.Errorf("pseudo-version time = %v, want %v", meta.LastCommitDate, want)
+	}
+	if meta.IsUnknown(SignalLastRelease) || !meta.IsUnknown(SignalReleaseHistory) || !meta.IsUnknown(SignalRepository) {
+		t.Errorf("pseudo-version unknown signals = %v", meta.Unknown)
+	}
+
+	// A tagged version carries no date: nothing is known, so it is not Risky
+	config := DefaultScoringConfig()
+	meta = vendorMetadata(one.Version)
+	score := CalculateHealthScore(meta, config)
+	if got := CategorizeModule(meta, score, config); got != Unknown {
+		t.Errorf("tagged vendored module categorized %v (score %d, unknown %v)", got, score, meta.Unknown)
+	}
+}
+
+func TestIdentifyLicense(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Apache License\n   Version 2.0, January 2004", "Apache-2.0"},
+		{"Redistribution and use in source and binary forms, with or without\nmodification... Neither the name of Google Inc.", "BSD-3-Clause"},
+		{"Redistribution and use in source and binary forms, with or without modification", "BSD-2-Clause"},
+		{"GNU LESSER GENERAL PUBLIC LICENSE\nVersion 3, 29 June 2007", "LGPL-3.0"},
+		{"GNU GENERAL PUBLIC LICENSE\nVersion 2, June 1991", "GPL-2.0"},
+		{"Mozilla Public License Version 2.0", "MPL-2.0"},
+		{"All rights reserved.", "Unknown"},
+	}
+	for _, tt := range tests {
+		if got := IdentifyLicense(tt.text); got != tt.want {
+			t.Errorf("IdentifyLicense(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
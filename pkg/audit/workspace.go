@@ -0,0 +1,178 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GoWorkFile is the parsed content of a go.work file
+type GoWorkFile struct {
+	Go        string           `json:"go,omitempty"`
+	Toolchain string           `json:"toolchain,omitempty"`
+	Godebug   []GodebugSetting `json:"godebug,omitempty"`
+	Use       []string         `json:"use,omitempty"` // module directories as written
+	Replace   []Replacement    `json:"replace,omitempty"`
+}
+
+// ParseGoWorkFile reads and parses the go.work file at path
+func ParseGoWorkFile(path string) (*GoWorkFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := ParseGoWorkData(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return f, nil
+}
+
+// ParseGoWorkData parses go.work content, which shares the go.mod syntax
+func ParseGoWorkData(data []byte) (*GoWorkFile, error) {
+	f := &GoWorkFile{}
+	err := parseModDirectives(data, func(d modDirective) error {
+		switch d.verb {
+		case "use":
+			if len(d.args) != 1 {
+				return fmt.Errorf("usage: use local/dir")
+			}
+			f.Use = append(f.Use, d.args[0].text)
+		case "replace":
+			r, err := parseReplace(d.args)
+			if err != nil {
+				return err
+			}
+			f.Replace = append(f.Replace, r)
+		case "go", "toolchain", "godebug":
+			// Same syntax and meaning as in go.mod
+			var mod GoModFile
+			if err := mod.add(d); err != nil {
+				return err
+			}
+			if mod.Go != "" {
+				f.Go = mod.Go
+			}
+			if mod.Toolchain != "" {
+				f.Toolchain = mod.Toolchain
+			}
+			f.Godebug = append(f.Godebug, mod.Godebug...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// FindGoWork returns the go.work file that applies to projectPath, or "" when
+// the project is not audited as a workspace. Like the go command it honors
+// GOWORK=off and an explicit GOWORK path, from the environment or 'go env
+// -w'; otherwise the nearest go.work in projectPath or a parent directory is
+// used.
+func FindGoWork(projectPath string) string {
+	switch gowork := goEnv("GOWORK"); gowork {
+	case "off":
+		return ""
+	case "", "auto":
+	default:
+		return gowork
+	}
+	dir, err := filepath.Abs(projectPath)
+	if err != nil {
+		return ""
+	}
+	for {
+		path := filepath.Join(dir, "go.work")
+		if fi, err := os.Stat(path); err == nil && !fi.IsDir() {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// ResolveWorkspace is Resolve for a go.work workspace: every use module is a
+// main module and versions are selected across all of them, so shared
+// dependencies appear once in the build list.
+func (r *Resolver) ResolveWorkspace(ctx context.Context, goworkPath string) ([]Module, *DependencyGraph, error) {
+	work, err := ParseGoWorkFile(goworkPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rules := workspaceRules{work: work, dir: filepath.Dir(goworkPath)}
+	var roots []resolveRoot
+	for _, use := range work.Use {
+		gomod := filepath.Join(resolveLocalPath(rules.dir, use), "go.mod")
+		file, err := ParseGoModFile(gomod)
+		if err != nil {
+			return nil, nil, err
+		}
+		if file.Module == "" {
+			return nil, nil, fmt.Errorf("%s: missing module directive", gomod)
+		}
+		roots = append(roots, resolveRoot{file: file, gomod: gomod})
+	}
+	if len(roots) == 0 {
+		return nil, nil, fmt.Errorf("%s: no use directives", goworkPath)
+	}
+	rules.modules = roots
+
+	return r.resolve(ctx, roots, rules)
+}
+
+// workspaceRules applies go.work replacements first, then those of the
+// workspace modules; exclusions of every workspace module apply
+type workspaceRules struct {
+	work    *GoWorkFile
+	dir     string
+	modules []resolveRoot
+}
+
+func (wr workspaceRules) replacement(path, version string) (ModuleVersion, string, bool) {
+	workFile := fileRules{file: &GoModFile{Replace: wr.work.Replace}, dir: wr.dir}
+	if repl, dir, ok := workFile.replacement(path, version); ok {
+		return repl, dir, true
+	}
+	for _, m := range wr.modules {
+		modFile := fileRules{file: m.file, dir: filepath.Dir(m.gomod)}
+		if repl, dir, ok := modFile.replacement(path, version); ok {
+			return repl, dir, true
+		}
+	}
+	return ModuleVersion{}, "", false
+}
+
+func (wr workspaceRules) excluded(path, version string) bool {
+	for _, m := range wr.modules {
+		if m.file.IsExcluded(path, version) {
+			return true
+		}
+	}
+	return false
+}
+
+// goCommandEnv returns the environment for go commands run against
+// projectPath. Workspace mode rejects -mod=mod, which some environments set
+// in GOFLAGS, so it is dropped there.
+func goCommandEnv(projectPath string) []string {
+	env := os.Environ()
+	if FindGoWork(projectPath) == "" {
+		return env
+	}
+	flags := strings.Fields(os.Getenv("GOFLAGS"))
+	kept := flags[:0]
+	for _, f := range flags {
+		if !strings.HasPrefix(f, "-mod=") && !strings.HasPrefix(f, "--mod=") {
+			kept = append(kept, f)
+		}
+	}
+	return append(env, "GOFLAGS="+strings.Join(kept, " "))
+}
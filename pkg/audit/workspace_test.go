@@ -0,0 +1,126 @@
+package audit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseGoWorkData(t *testing.T) {
+	data := `go 1.22
+
+toolchain go1.22.1
+
+use (
+	./svc/api
+	"./svc/worker" // quoted
+)
+use ./lib
+
+replace example.com/shared v1.0.0 => ./shared
+`
+	f, err := ParseGoWorkData([]byte(data))
+	if err != nil {
+		t.Fatalf("ParseGoWorkData() error = %v", err)
+	}
+	if f.Go != "1.22" || f.Toolchain != "go1.22.1" {
+		t.Errorf("go/toolchain = %q %q", f.Go, f.Toolchain)
+	}
+	if want := []string{"./svc/api", "./svc/worker", "./lib"}; !reflect.DeepEqual(f.Use, want) {
+		t.Errorf("Use = %v, want %v", f.Use, want)
+	}
+	if len(f.Replace) != 1 || f.Replace[0].New.Path != "./shared" {
+		t.Errorf("Replace = %+v", f.Replace)
+	}
+}
+
+func TestResolveWorkspace(t *testing.T) {
+	writeModCache(t, map[string]string{
+		"example.com/shared@v1.0.0": "module example.com/shared\ngo 1.21\n",
+		"example.com/shared@v1.1.0": "module example.com/shared\ngo 1.21\n",
+		"example.com/only@v1.0.0":   "module example.com/only\ngo 1.21\nrequire example.com/leaf v1.0.0\n",
+	})
+
+	root := t.TempDir()
+	files := map[string]string{
+		"go.work":  "go 1.21\n\nuse (\n\t./a\n\t./b\n)\n",
+		"a/go.mod": "module example.com/a\n\ngo 1.21\n\nrequire example.com/shared v1.0.0\n",
+		"b/go.mod": "module example.com/b\n\ngo 1.21\n\nrequire (\n\texample.com/shared v1.1.0\n\texample.com/only v1.0.0\n)\n",
+	}
+	for name, content := range files {
+		p := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	modules, graph, err := NewResolver(NewFetcher(AuditConfig{})).ResolveWorkspace(context.Background(), filepath.Join(root, "go.work"))
+	if err != nil {
+		t.Fatalf("ResolveWorkspace() error = %v", err)
+	}
+
+	versions := make(map[string]string)
+	mains := 0
+	for _, m := range modules {
+		if m.Main {
+			mains++
+			continue
+		}
+		versions[m.Path] = m.Version
+	}
+	if mains != 2 {
+		t.Errorf("got %d main modules, want 2", mains)
+	}
+	// shared is selected once, at the highest version any module needs
+	want := map[string]string{
+		"example.com/shared": "v1.1.0",
+		"example.com/only":   "v1.0.0",
+		"example.com/leaf":   "v1.0.0",
+	}
+	if !reflect.DeepEqual(versions, want) {
+		t.Errorf("build list = %v, want %v", versions, want)
+	}
+
+	requiredBy := graph.RequiredBy()
+	if got := requiredBy["example.com/shared"]; !reflect.DeepEqual(got, []string{"example.com/a", "example.com/b"}) {
+		t.Errorf("RequiredBy(shared) = %v", got)
+	}
+	if got := requiredBy["example.com/leaf"]; !reflect.DeepEqual(got, []string{"example.com/b"}) {
+		t.Errorf("RequiredBy(leaf) = %v", got)
+	}
+}
+
+func TestFindGoWork(t *testing.T) {
+	t.Setenv("GOENV", "off")
+	t.Setenv("GOWORK", "")
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.work":             "go 1.21\n\nuse ./services/api\n",
+		"services/api/go.mod": "module example.com/api\n\ngo 1.21\n",
+	})
+	want := filepath.Join(root, "go.work")
+
+	// Like the go command, a go.work in a parent directory applies
+	for _, dir := range []string{root, filepath.Join(root, "services", "api")} {
+		if got := FindGoWork(dir); got != want {
+			t.Errorf("FindGoWork(%s) = %q, want %q", dir, got, want)
+		}
+	}
+	if got := FindGoWork(t.TempDir()); got != "" {
+		t.Errorf("FindGoWork() outside the workspace = %q", got)
+	}
+
+	t.Setenv("GOWORK", "off")
+	if got := FindGoWork(root); got != "" {
+		t.Errorf("FindGoWork() with GOWORK=off = %q", got)
+	}
+	t.Setenv("GOWORK", "/elsewhere/go.work")
+	if got := FindGoWork(root); got != "/elsewhere/go.work" {
+		t.Errorf("FindGoWork() with GOWORK set = %q", got)
+	}
+}